@@ -0,0 +1,66 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker while the circuit is open.
+var ErrCircuitOpen = fmt.Errorf("circuit open")
+
+// CircuitBreaker returns a Task that stops calling the task after threshold consecutive failures.
+// While open, the Task returns ErrCircuitOpen immediately until the cooldown has elapsed.
+// After the cooldown, a single invocation is allowed through as a probe while any others still return ErrCircuitOpen.
+// A successful probe closes the circuit, while a failed probe opens it for another cooldown.
+// Errors caused by the caller's context being done are not counted as failures.
+// The returned Task is safe to run concurrently and shares its state between invocations.
+func CircuitBreaker(t Task, threshold int, cooldown time.Duration) Task {
+	var mutex sync.Mutex
+
+	failures := 0
+	probing := false
+	var opened time.Time
+
+	return func(ctx context.Context) (err error) {
+		mutex.Lock()
+
+		probe := false
+		if failures >= threshold {
+			if probing || time.Since(opened) < cooldown {
+				mutex.Unlock()
+				return ErrCircuitOpen
+			}
+
+			// Half-open: let a single invocation through.
+			probing = true
+			probe = true
+		}
+
+		mutex.Unlock()
+
+		err = t(ctx)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if probe {
+			probing = false
+		}
+
+		switch {
+		case err == nil:
+			failures = 0
+		case ctx.Err() != nil:
+			// Cancelled by the caller, not the task's fault.
+		default:
+			failures += 1
+			if failures >= threshold {
+				opened = time.Now()
+			}
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,127 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the breaker trips, waits for the cooldown, and closes after a successful probe.
+func TestCircuitBreaker(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	fail := true
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		if fail {
+			return errSample
+		}
+
+		return nil
+	}
+
+	breaker := invoker.CircuitBreaker(f, 2, 10*time.Millisecond)
+	ctx := context.Background()
+
+	require.Equal(errSample, breaker(ctx))
+	require.Equal(errSample, breaker(ctx))
+
+	// Tripped, so the task is no longer called.
+	require.Equal(invoker.ErrCircuitOpen, breaker(ctx))
+	require.Equal(2, count)
+
+	time.Sleep(20 * time.Millisecond)
+	fail = false
+
+	// The probe succeeds and closes the circuit.
+	require.NoError(breaker(ctx))
+	require.NoError(breaker(ctx))
+	require.Equal(4, count)
+}
+
+// Test that a failed probe opens the circuit for another cooldown.
+func TestCircuitBreakerProbeFail(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		return errSample
+	}
+
+	breaker := invoker.CircuitBreaker(f, 1, 10*time.Millisecond)
+	ctx := context.Background()
+
+	require.Equal(errSample, breaker(ctx))
+	require.Equal(invoker.ErrCircuitOpen, breaker(ctx))
+
+	time.Sleep(20 * time.Millisecond)
+
+	require.Equal(errSample, breaker(ctx))
+	require.Equal(invoker.ErrCircuitOpen, breaker(ctx))
+	require.Equal(2, count)
+}
+
+// Test that only a single probe is allowed while half-open.
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	first := true
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	f := func(ctx context.Context) (err error) {
+		if first {
+			first = false
+			return errSample
+		}
+
+		close(started)
+		<-release
+
+		return nil
+	}
+
+	breaker := invoker.CircuitBreaker(f, 1, time.Millisecond)
+	ctx := context.Background()
+
+	require.Equal(errSample, breaker(ctx))
+	time.Sleep(5 * time.Millisecond)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- breaker(ctx)
+	}()
+
+	<-started
+
+	// The probe is still running, so others are rejected.
+	require.Equal(invoker.ErrCircuitOpen, breaker(ctx))
+
+	close(release)
+	require.NoError(<-errs)
+}
+
+// Test that cancellation by the caller doesn't count as a failure.
+func TestCircuitBreakerCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	breaker := invoker.CircuitBreaker(invoker.Wait, 1, time.Hour)
+
+	require.Equal(context.Canceled, breaker(ctx))
+	require.Equal(context.Canceled, breaker(ctx))
+}
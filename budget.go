@@ -0,0 +1,61 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExhausted is returned by tasks added with AddBudgeted when the budget has no time remaining.
+// It wraps context.DeadlineExceeded.
+var ErrBudgetExhausted = fmt.Errorf("budget exhausted: %w", context.DeadlineExceeded)
+
+// Budget is a total duration shared by tasks, ex. a request timeout split across downstream calls.
+// The budget starts when it's created, so tasks that start later receive less time.
+// It's safe for concurrent use.
+type Budget struct {
+	deadline time.Time
+}
+
+// NewBudget returns a budget that expires after the total duration.
+func NewBudget(total time.Duration) *Budget {
+	return &Budget{deadline: time.Now().Add(total)}
+}
+
+// Deadline returns the time when the budget is exhausted.
+func (b *Budget) Deadline() time.Time {
+	return b.deadline
+}
+
+// Remaining returns the time left in the budget, or zero once it's exhausted.
+func (b *Budget) Remaining() time.Duration {
+	return max(time.Until(b.deadline), 0)
+}
+
+// AddBudgeted adds a task with a context deadline set to the remaining budget when it starts.
+// A task that would start after the budget is exhausted is skipped, returning ErrBudgetExhausted.
+// An earlier deadline on the group's context still applies.
+// Returns a handle that can cancel the task, like Add.
+func (ts *Tasks) AddBudgeted(b *Budget, t Task) (h *TaskHandle) {
+	h = new(TaskHandle)
+
+	h.err = ts.lockRoom(context.Background(), 1)
+	if h.err != nil {
+		return h
+	}
+
+	defer ts.mutex.Unlock()
+
+	ts.add(h.wrap(func(ctx context.Context) (err error) {
+		if b.Remaining() == 0 {
+			return ErrBudgetExhausted
+		}
+
+		ctx, cancel := context.WithDeadlineCause(ctx, b.deadline, ErrBudgetExhausted)
+		defer cancel()
+
+		return t(ctx)
+	}), 1)
+
+	return h
+}
@@ -0,0 +1,95 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that tasks starting later receive less of the budget.
+func TestBudget(t *testing.T) {
+	require := require.New(t)
+
+	b := invoker.NewBudget(time.Second)
+
+	var mutex sync.Mutex
+	var remaining []time.Duration
+
+	f := func(ctx context.Context) (err error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return errors.New("missing deadline")
+		}
+
+		mutex.Lock()
+		remaining = append(remaining, time.Until(deadline))
+		mutex.Unlock()
+
+		return invoker.Sleep(10 * time.Millisecond)(ctx)
+	}
+
+	// Run one at a time so each task starts after the previous.
+	tasks := invoker.New().Apply(invoker.WithLimit(1))
+	for i := 0; i < 3; i += 1 {
+		tasks.AddBudgeted(b, f)
+	}
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(3, len(remaining))
+
+	for i := 1; i < len(remaining); i += 1 {
+		require.True(remaining[i] <= remaining[i-1]-10*time.Millisecond, "%v", remaining)
+	}
+
+	require.True(remaining[0] <= time.Second)
+	require.True(b.Remaining() < time.Second)
+}
+
+// Test that a task is skipped once the budget is exhausted.
+func TestBudgetExhausted(t *testing.T) {
+	require := require.New(t)
+
+	b := invoker.NewBudget(0)
+	require.Equal(time.Duration(0), b.Remaining())
+
+	ran := false
+	f := func(ctx context.Context) (err error) {
+		ran = true
+		return nil
+	}
+
+	tasks := invoker.New()
+	tasks.AddBudgeted(b, f)
+
+	err := tasks.Run(context.Background())
+	require.Equal(invoker.ErrBudgetExhausted, err)
+	require.True(errors.Is(err, context.DeadlineExceeded))
+	require.False(ran)
+}
+
+// Test that a task still running when the budget runs out is cancelled.
+func TestBudgetCancel(t *testing.T) {
+	require := require.New(t)
+
+	b := invoker.NewBudget(10 * time.Millisecond)
+
+	var cause error
+	f := func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		cause = context.Cause(ctx)
+		return ctx.Err()
+	}
+
+	tasks := invoker.New()
+	tasks.AddBudgeted(b, f)
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(invoker.ErrBudgetExhausted, cause)
+}
@@ -0,0 +1,63 @@
+package invoker
+
+import (
+	"context"
+	"sync"
+)
+
+// Callbacks bridges panics in callbacks invoked from another library's goroutines into the group.
+// Those panics happen outside of any task, so they can't be recovered by Recover or WithPanicLimit.
+// The zero value is ready to use.
+type Callbacks struct {
+	once sync.Once
+
+	// holds the first panic until it's returned by Wait
+	panics chan error
+}
+
+// init creates the channel.
+func (c *Callbacks) init() {
+	c.once.Do(func() {
+		c.panics = make(chan error, 1)
+	})
+}
+
+// SafeCallback wraps the callback, converting a panic into an ErrPanic that's returned to the library.
+// The first panic is also returned by Wait, so the group fails even if the library ignores the error.
+func (c *Callbacks) SafeCallback(fn func() error) func() error {
+	c.init()
+
+	return func() (err error) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			err = ErrPanic{value: p, stack: stack()}
+
+			// Only the first panic is kept, the rest are only returned to the library.
+			select {
+			case c.panics <- err:
+			default:
+			}
+		}()
+
+		return fn()
+	}
+}
+
+// Wait returns a Task that blocks until a wrapped callback panics, returning the ErrPanic.
+// Otherwise it returns the context error once the context is done.
+func (c *Callbacks) Wait() (t Task) {
+	c.init()
+
+	return func(ctx context.Context) (err error) {
+		select {
+		case err = <-c.panics:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
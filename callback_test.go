@@ -0,0 +1,84 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// library invokes the callback from its own goroutine, ignoring any error.
+func library(callback func() error) {
+	go func() {
+		_ = callback()
+	}()
+}
+
+// Test that a panic in a callback from a library goroutine fails the group.
+func TestSafeCallback(t *testing.T) {
+	require := require.New(t)
+
+	var callbacks invoker.Callbacks
+
+	callback := callbacks.SafeCallback(func() error {
+		panic("oops")
+	})
+
+	start := func(ctx context.Context) (err error) {
+		library(callback)
+
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	err := invoker.Run(context.Background(), callbacks.Wait(), start)
+
+	var ep invoker.ErrPanic
+	require.True(errors.As(err, &ep))
+	require.Equal("oops", ep.Value())
+	require.NotEmpty(ep.Stack())
+}
+
+// Test that a wrapped callback returns its result and the panic to the library.
+func TestSafeCallbackResult(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	var callbacks invoker.Callbacks
+
+	err := callbacks.SafeCallback(func() error {
+		return errSample
+	})()
+	require.Equal(errSample, err)
+
+	err = callbacks.SafeCallback(func() error {
+		panic("first")
+	})()
+	require.True(errors.As(err, new(invoker.ErrPanic)))
+
+	// Only the first panic is reported to the group.
+	err = callbacks.SafeCallback(func() error {
+		panic("second")
+	})()
+	require.True(errors.As(err, new(invoker.ErrPanic)))
+
+	err = invoker.Run(context.Background(), callbacks.Wait())
+	require.Equal("first", err.(invoker.ErrPanic).Value())
+}
+
+// Test that Wait returns the context error without a panic.
+func TestSafeCallbackCancel(t *testing.T) {
+	require := require.New(t)
+
+	var callbacks invoker.Callbacks
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, callbacks.Wait())
+	require.Equal(context.Canceled, err)
+}
@@ -0,0 +1,70 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrTooManyTasks is returned when adding tasks beyond WithMaxRunning.
+var ErrTooManyTasks = fmt.Errorf("too many tasks")
+
+// WithMaxRunning caps the number of tasks that can be running or queued once Run/Race/Repeat has started.
+// This prevents a runaway loop of tasks adding tasks from exhausting resources.
+// If block is false, tasks added beyond the cap are not added and TaskHandle.Err returns ErrTooManyTasks.
+// If block is true, adding tasks blocks until enough tasks have returned or the group is cancelled.
+// Adding more tasks at once than the cap is always rejected with ErrTooManyTasks, as there would never be room.
+// TryAdd returns false instead of exceeding the cap, and AddWait returns ErrTooManyTasks or blocks until its context is done.
+// Tasks added with Phases are not limited.
+// NOTE: With block, a group can deadlock if every running task is blocked adding another task.
+func WithMaxRunning(n int, block bool) Option {
+	return func(ts *Tasks) {
+		ts.maxRunning = n
+		ts.maxBlock = block
+	}
+}
+
+// full returns true if adding the given number of tasks would exceed WithMaxRunning.
+// Tasks added before Run/Race/Repeat are not limited, nor are tasks added once the group is cancelled as they return immediately.
+// The mutex must be held.
+func (ts *Tasks) full(extra int) bool {
+	if ts.maxRunning <= 0 || ts.mode == modeInit || ts.mode == modeDone || ts.ctx.Err() != nil {
+		return false
+	}
+
+	return len(ts.live)+len(ts.pending)+extra > ts.maxRunning
+}
+
+// lockRoom locks the mutex once there's room for the given number of tasks under WithMaxRunning.
+// If there's no room, ErrTooManyTasks is returned without the mutex, or it waits for a task to return if block is set and waiting could make room.
+// The context error is returned without the mutex if the context is done while waiting.
+func (ts *Tasks) lockRoom(ctx context.Context, extra int) (err error) {
+	for {
+		ts.mutex.Lock()
+
+		if !ts.full(extra) {
+			return nil
+		}
+
+		if !ts.maxBlock || extra > ts.maxRunning {
+			// Blocking is disabled, or there would never be room for this many tasks.
+			ts.mutex.Unlock()
+			return ErrTooManyTasks
+		}
+
+		if ts.slot == nil {
+			ts.slot = make(chan struct{})
+		}
+
+		slot := ts.slot
+		cancelled := ts.ctx.Done()
+
+		ts.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-slot:
+		case <-cancelled:
+		}
+	}
+}
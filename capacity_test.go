@@ -0,0 +1,175 @@
+package invoker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that tasks added beyond the cap are rejected without failing the group.
+func TestMaxRunning(t *testing.T) {
+	require := require.New(t)
+
+	// Room for the loop, the timeout, and one more.
+	tasks := invoker.New().Apply(invoker.WithMaxRunning(3, false))
+
+	started := uint64(0)
+	wait := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&started, 1)
+
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	// A runaway loop that keeps adding tasks.
+	rejected := 0
+	tasks.Add(func(ctx context.Context) (err error) {
+		for i := 0; i < 5; i += 1 {
+			if tasks.Add(wait).Err() == invoker.ErrTooManyTasks {
+				rejected += 1
+			}
+		}
+
+		return nil
+	})
+
+	tasks.Add(invoker.Timeout(10 * time.Millisecond))
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.DeadlineExceeded, err)
+
+	// Only one more task fit under the cap, and the rest didn't cancel the group.
+	require.Equal(4, rejected)
+	require.Equal(uint64(1), atomic.LoadUint64(&started))
+}
+
+// Test that tasks added before Run are not limited, and TryAdd refuses to exceed the cap.
+func TestMaxRunningTryAdd(t *testing.T) {
+	require := require.New(t)
+
+	release := make(chan struct{})
+	wait := func(ctx context.Context) (err error) {
+		<-release
+		return nil
+	}
+
+	tasks := invoker.New(wait, wait, wait).Apply(invoker.WithMaxRunning(3, false))
+
+	added := make(chan bool, 1)
+	tasks.Add(func(ctx context.Context) (err error) {
+		_, ok := tasks.TryAdd(wait)
+		added <- ok
+		close(release)
+
+		return nil
+	})
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.False(<-added)
+}
+
+// Test that adding beyond the cap blocks until a task returns.
+func TestMaxRunningBlock(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New().Apply(invoker.WithMaxRunning(2, true))
+
+	release := make(chan struct{})
+	returned := uint64(0)
+
+	slow := func(ctx context.Context) (err error) {
+		<-release
+		atomic.AddUint64(&returned, 1)
+
+		return nil
+	}
+
+	blocked := uint64(0)
+
+	tasks.Add(func(ctx context.Context) (err error) {
+		tasks.Add(slow)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+		}()
+
+		// Blocks until the slow task returns.
+		tasks.Add(slow)
+		atomic.StoreUint64(&blocked, atomic.LoadUint64(&returned))
+
+		return nil
+	})
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.True(atomic.LoadUint64(&blocked) >= 1)
+	require.Equal(uint64(2), atomic.LoadUint64(&returned))
+}
+
+// Test that a blocked add gives up waiting once the group is cancelled.
+func TestMaxRunningBlockCancel(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New().Apply(invoker.WithMaxRunning(2, true))
+
+	tasks.Add(func(ctx context.Context) (err error) {
+		// Blocks until the timeout cancels the group.
+		return tasks.Add(invoker.Wait).Err()
+	})
+
+	tasks.Add(invoker.Timeout(10 * time.Millisecond))
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that AddWait respects the cap, returning an error or waiting for its context.
+func TestMaxRunningAddWait(t *testing.T) {
+	require := require.New(t)
+
+	for _, block := range []bool{false, true} {
+		tasks := invoker.New().Apply(invoker.WithMaxRunning(1, block))
+
+		var added error
+		tasks.Add(func(ctx context.Context) (err error) {
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+
+			_, added = tasks.AddWait(ctx, invoker.Noop)
+
+			return nil
+		})
+
+		err := tasks.Run(context.Background())
+		require.NoError(err)
+
+		if block {
+			require.Equal(context.DeadlineExceeded, added)
+		} else {
+			require.Equal(invoker.ErrTooManyTasks, added)
+		}
+	}
+}
+
+// Test that adding more tasks at once than the cap is rejected instead of blocking forever.
+func TestMaxRunningBlockTooMany(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New().Apply(invoker.WithMaxRunning(3, true))
+
+	var added error
+	tasks.Add(func(ctx context.Context) (err error) {
+		added = tasks.Add(invoker.Noop, invoker.Noop, invoker.Noop, invoker.Noop).Err()
+		return nil
+	})
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(invoker.ErrTooManyTasks, added)
+}
@@ -0,0 +1,69 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrClosed is the cancellation cause when Close stops the group.
+var ErrClosed = fmt.Errorf("closed")
+
+// Close stops the group from accepting new tasks; any further tasks are dropped.
+// If drain is true, queued and running tasks are given until the context is done to finish.
+// Otherwise, or once the context is done, any queued tasks are discarded and running tasks are cancelled.
+// Returns nil if the group finished on its own, otherwise the context error.
+// Close doesn't wait for cancelled tasks to return; use Wait for the final result.
+func (ts *Tasks) Close(ctx context.Context, drain bool) (err error) {
+	ts.mutex.Lock()
+	ts.closed = true
+	mode := ts.mode
+	ts.mutex.Unlock()
+
+	switch mode {
+	case modeInit:
+		if !drain {
+			ts.abort()
+		}
+
+		return nil
+	case modeDone:
+		return nil
+	}
+
+	if drain {
+		select {
+		case <-ts.Done():
+			return nil
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	ts.abort()
+
+	return err
+}
+
+// abort discards any queued tasks and cancels the running tasks.
+func (ts *Tasks) abort() {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.pending = nil
+
+	switch ts.mode {
+	case modeInit, modeDone:
+		return
+	}
+
+	ts.cancel(ErrClosed)
+
+	if ts.running == 0 {
+		// Nothing will report, so finish now.
+		if ts.err == nil {
+			ts.err = ts.ctx.Err()
+		}
+
+		ts.complete()
+	}
+}
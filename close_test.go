@@ -0,0 +1,141 @@
+package invoker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a graceful close finishes the queued tasks.
+func TestCloseDrain(t *testing.T) {
+	require := require.New(t)
+
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		started <- struct{}{}
+		<-release
+		atomic.AddUint64(&count, 1)
+
+		return nil
+	}
+
+	tasks := invoker.New(f, f, f).Apply(invoker.WithLimit(1))
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tasks.Run(context.Background())
+	}()
+
+	<-started
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- tasks.Close(context.Background(), true)
+	}()
+
+	close(release)
+
+	require.NoError(<-closed)
+	require.NoError(<-errs)
+	require.Equal(uint64(3), atomic.LoadUint64(&count))
+
+	// New tasks are no longer accepted.
+	_, ok := tasks.TryAdd(f)
+	require.False(ok)
+
+	_, err := tasks.AddWait(context.Background(), f)
+	require.Equal(invoker.ErrClosed, err)
+}
+
+// Test that an abort discards the queued tasks and cancels the running ones.
+func TestCloseAbort(t *testing.T) {
+	require := require.New(t)
+
+	started := make(chan struct{}, 3)
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		started <- struct{}{}
+
+		return invoker.Wait(ctx)
+	}
+
+	tasks := invoker.New(f, f, f).Apply(invoker.WithLimit(1))
+
+	outcomes := make(chan *invoker.Outcome, 1)
+	errs := make(chan error, 1)
+	go func() {
+		o, err := tasks.RunResult(context.Background())
+		outcomes <- o
+		errs <- err
+	}()
+
+	<-started
+
+	err := tasks.Close(context.Background(), false)
+	require.NoError(err)
+	require.Equal(invoker.ErrClosed, (<-outcomes).Cause)
+	require.Equal(context.Canceled, <-errs)
+	require.Equal(uint64(1), atomic.LoadUint64(&count))
+}
+
+// Test that draining gives up once the context is done.
+func TestCloseDrainTimeout(t *testing.T) {
+	require := require.New(t)
+
+	started := make(chan struct{}, 3)
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		started <- struct{}{}
+
+		return invoker.Wait(ctx)
+	}
+
+	tasks := invoker.New(f, f).Apply(invoker.WithLimit(1))
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tasks.Run(context.Background())
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := tasks.Close(ctx, true)
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(context.Canceled, <-errs)
+	require.Equal(uint64(1), atomic.LoadUint64(&count))
+}
+
+// Test that an abort finishes the group even when nothing is running.
+func TestCloseAbortPaused(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New(invoker.Noop, invoker.Noop)
+	tasks.Pause()
+
+	modes := tasks.Subscribe()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tasks.Run(context.Background())
+	}()
+
+	require.Equal(invoker.ModeRun, <-modes)
+
+	err := tasks.Close(context.Background(), false)
+	require.NoError(err)
+	require.Equal(context.Canceled, <-errs)
+}
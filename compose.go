@@ -0,0 +1,213 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Fallback returns a Task that runs each task in order until one returns nil.
+// If every task fails, the errors are joined together.
+// No further tasks are run once the context is done.
+func Fallback(tasks ...Task) (t Task) {
+	return func(ctx context.Context) (err error) {
+		var errs []error
+
+		for _, t := range tasks {
+			if ctx.Err() != nil {
+				errs = append(errs, ctx.Err())
+				break
+			}
+
+			err = t(ctx)
+			if err == nil {
+				return nil
+			}
+
+			errs = append(errs, err)
+		}
+
+		return errors.Join(errs...)
+	}
+}
+
+// Composite combines sub-groups with different modes into a single lifecycle.
+// Every sub-group shares one context, and the first sub-group to finish cancels the rest.
+// The result is that of the first sub-group to finish, ex. a Run sub-group's first error or a Race sub-group's first result.
+// The zero value is ready to use.
+type Composite struct {
+	groups []Task
+}
+
+// AddRun adds a sub-group that finishes once every task has returned nil, or on the first error.
+func (c *Composite) AddRun(tasks ...Task) *Composite {
+	c.groups = append(c.groups, func(ctx context.Context) (err error) {
+		return Run(ctx, tasks...)
+	})
+
+	return c
+}
+
+// AddRace adds a sub-group that finishes with the first result of any task.
+func (c *Composite) AddRace(tasks ...Task) *Composite {
+	c.groups = append(c.groups, func(ctx context.Context) (err error) {
+		return Race(ctx, tasks...)
+	})
+
+	return c
+}
+
+// Run executes every sub-group until the first one finishes, cancelling the rest.
+func (c *Composite) Run(ctx context.Context) (err error) {
+	return Race(ctx, c.groups...)
+}
+
+// WaitAll returns a Task that blocks until every group has finished, returning their errors joined together.
+// The groups are started elsewhere, so this supervises independent subsystems without running them.
+// The context error is returned if the context is done first; a group that is never started blocks until then.
+func WaitAll(groups ...*Tasks) (t Task) {
+	return func(ctx context.Context) (err error) {
+		errs := make([]error, 0, len(groups))
+
+		for _, ts := range groups {
+			select {
+			case <-ts.Done():
+				errs = append(errs, ts.Err())
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		return errors.Join(errs...)
+	}
+}
+
+// RaceBoth returns a Task that runs both tasks and returns the result of whichever finishes first, without cancelling the other.
+// The results function blocks until both tasks have returned and reports their errors, ex. to compare a shadow implementation against the original.
+// It reports the most recent run if the Task is run multiple times.
+// NOTE: The loser is detached once the winner returns, so it keeps running even if the context is then cancelled.
+func RaceBoth(a Task, b Task) (t Task, results func() (aErr error, bErr error)) {
+	var mutex sync.Mutex
+
+	// The results function waits on the latest run, which may not have started yet.
+	latest := newBothRun()
+
+	results = func() (aErr error, bErr error) {
+		mutex.Lock()
+		run := latest
+		mutex.Unlock()
+
+		<-run.done
+
+		return run.aErr, run.bErr
+	}
+
+	t = func(ctx context.Context) (err error) {
+		mutex.Lock()
+
+		if latest.started {
+			latest = newBothRun()
+		}
+
+		run := latest
+		run.started = true
+		mutex.Unlock()
+
+		// Cancelled with the parent until the winner returns, then detached.
+		inner, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+		stop := context.AfterFunc(ctx, func() {
+			cancel(context.Cause(ctx))
+		})
+
+		aErr := make(chan error, 1)
+		bErr := make(chan error, 1)
+
+		go func() {
+			aErr <- a(inner)
+		}()
+
+		go func() {
+			bErr <- b(inner)
+		}()
+
+		// Wait for the loser in the background.
+		first := make(chan error, 1)
+
+		go func() {
+			defer cancel(nil)
+
+			for i := 0; i < 2; i += 1 {
+				select {
+				case err := <-aErr:
+					run.aErr = err
+					aErr = nil
+
+					if i == 0 {
+						first <- err
+					}
+				case err := <-bErr:
+					run.bErr = err
+					bErr = nil
+
+					if i == 0 {
+						first <- err
+					}
+				}
+			}
+
+			close(run.done)
+		}()
+
+		err = <-first
+		stop()
+
+		return err
+	}
+
+	return t, results
+}
+
+// bothRun is a single run of RaceBoth.
+type bothRun struct {
+	started bool
+	aErr    error
+	bErr    error
+
+	// closed once both tasks have returned
+	done chan struct{}
+}
+
+func newBothRun() *bothRun {
+	return &bothRun{done: make(chan struct{})}
+}
+
+// Then returns a Task that runs the task and then next, only if the task returned nil.
+// Next is not run if the context is done in between, returning the context error instead.
+func (t Task) Then(next Task) Task {
+	return func(ctx context.Context) (err error) {
+		err = t(ctx)
+		if err != nil {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return next(ctx)
+	}
+}
+
+// Catch returns a Task that passes any error from the task to the handler, returning its result instead.
+// The handler can return nil to recover, or a different error.
+// NOTE: The handler is also called for errors caused by cancellation.
+func (t Task) Catch(handler func(err error) error) Task {
+	return func(ctx context.Context) (err error) {
+		err = t(ctx)
+		if err != nil {
+			return handler(err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,338 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the remaining tasks aren't run after the first success.
+func TestFallbackFirst(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		return nil
+	}
+
+	err := invoker.Fallback(f, f)(context.Background())
+	require.NoError(err)
+	require.Equal(1, count)
+}
+
+// Test that a later task can succeed.
+func TestFallbackLater(t *testing.T) {
+	require := require.New(t)
+
+	fail := func(ctx context.Context) (err error) {
+		return fmt.Errorf("hello")
+	}
+
+	err := invoker.Fallback(fail, fail, invoker.Noop)(context.Background())
+	require.NoError(err)
+}
+
+// Test that every error is joined when all tasks fail.
+func TestFallbackError(t *testing.T) {
+	require := require.New(t)
+
+	errFirst := fmt.Errorf("first")
+	errSecond := fmt.Errorf("second")
+
+	fail := func(err error) invoker.Task {
+		return func(ctx context.Context) error {
+			return err
+		}
+	}
+
+	err := invoker.Fallback(fail(errFirst), fail(errSecond))(context.Background())
+	require.True(errors.Is(err, errFirst))
+	require.True(errors.Is(err, errSecond))
+}
+
+// Test that no further tasks are run after cancellation.
+func TestFallbackCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		cancel()
+		return errSample
+	}
+
+	err := invoker.Fallback(f, f)(ctx)
+	require.True(errors.Is(err, errSample))
+	require.True(errors.Is(err, context.Canceled))
+	require.Equal(1, count)
+}
+
+// Test that a race sub-group finishing first cancels a run sub-group.
+func TestComposite(t *testing.T) {
+	require := require.New(t)
+
+	errs := make(chan error, 2)
+	worker := func(ctx context.Context) (err error) {
+		err = invoker.Wait(ctx)
+		errs <- err
+
+		return err
+	}
+
+	var c invoker.Composite
+	c.AddRun(worker, worker)
+	c.AddRace(invoker.Wait, invoker.Timer(time.Millisecond))
+
+	err := c.Run(context.Background())
+	require.NoError(err)
+	require.Equal(context.Canceled, <-errs)
+	require.Equal(context.Canceled, <-errs)
+}
+
+// Test that an error in a run sub-group ends the race sub-group.
+func TestCompositeError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	err := new(invoker.Composite).
+		AddRun(invoker.Noop, fail).
+		AddRace(invoker.Wait, invoker.Wait).
+		Run(context.Background())
+	require.Equal(errSample, err)
+}
+
+// Test that WaitAll joins the errors of every group once they have all finished.
+func TestWaitAll(t *testing.T) {
+	require := require.New(t)
+
+	errFirst := fmt.Errorf("first")
+	errSecond := fmt.Errorf("second")
+
+	release := make(chan struct{})
+	slow := func(ctx context.Context) (err error) {
+		<-release
+		return errSecond
+	}
+
+	ok := invoker.New(func(ctx context.Context) (err error) {
+		return nil
+	})
+
+	failed := invoker.New(func(ctx context.Context) (err error) {
+		return errFirst
+	})
+
+	pending := invoker.New(slow)
+
+	for _, ts := range []*invoker.Tasks{ok, failed, pending} {
+		go ts.Run(context.Background())
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- invoker.Run(context.Background(), invoker.WaitAll(ok, failed, pending))
+	}()
+
+	// The slow group hasn't finished yet.
+	select {
+	case err := <-errs:
+		require.Fail("returned early", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+
+	err := <-errs
+	require.Error(err)
+	require.True(errors.Is(err, errFirst))
+	require.True(errors.Is(err, errSecond))
+
+	// Every group succeeded.
+	err = invoker.Run(context.Background(), invoker.WaitAll(ok))
+	require.NoError(err)
+}
+
+// Test that WaitAll returns the context error if cancelled before the groups finish.
+func TestWaitAllCancel(t *testing.T) {
+	require := require.New(t)
+
+	// Never started, so never finishes.
+	idle := invoker.New(invoker.Wait)
+
+	err := invoker.Run(context.Background(), invoker.WaitAll(idle), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that RaceBoth returns the winner while the loser keeps running, reporting both results.
+func TestRaceBoth(t *testing.T) {
+	require := require.New(t)
+
+	errOld := fmt.Errorf("old")
+	errNew := fmt.Errorf("new")
+
+	old := func(ctx context.Context) (err error) {
+		return errOld
+	}
+
+	release := make(chan struct{})
+	shadow := func(ctx context.Context) (err error) {
+		<-release
+
+		// Not cancelled, even though the group has finished.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return errNew
+	}
+
+	task, results := invoker.RaceBoth(old, shadow)
+
+	err := invoker.Run(context.Background(), task)
+	require.Equal(errOld, err)
+
+	reported := make(chan struct{})
+
+	var aErr, bErr error
+	go func() {
+		aErr, bErr = results()
+		close(reported)
+	}()
+
+	// The results wait for the loser.
+	select {
+	case <-reported:
+		require.Fail("reported early")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	<-reported
+
+	require.Equal(errOld, aErr)
+	require.Equal(errNew, bErr)
+}
+
+// Test that RaceBoth cancels both tasks if the context is cancelled before either returns.
+func TestRaceBothCancel(t *testing.T) {
+	require := require.New(t)
+
+	task, results := invoker.RaceBoth(invoker.Wait, invoker.Wait)
+
+	err := invoker.Run(context.Background(), task, invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+
+	aErr, bErr := results()
+	require.Equal(context.Canceled, aErr)
+	require.Equal(context.Canceled, bErr)
+}
+
+// Test that Then runs each task in order while they succeed.
+func TestThen(t *testing.T) {
+	require := require.New(t)
+
+	var order []int
+	step := func(i int) invoker.Task {
+		return func(ctx context.Context) (err error) {
+			order = append(order, i)
+			return nil
+		}
+	}
+
+	err := invoker.Run(context.Background(), step(0).Then(step(1)).Then(step(2)))
+	require.NoError(err)
+	require.Equal([]int{0, 1, 2}, order)
+}
+
+// Test that an error short-circuits the chain.
+func TestThenError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	called := false
+	next := func(ctx context.Context) (err error) {
+		called = true
+		return nil
+	}
+
+	fail := invoker.Task(func(ctx context.Context) (err error) {
+		return errSample
+	})
+
+	err := invoker.Run(context.Background(), fail.Then(next))
+	require.Equal(errSample, err)
+	require.False(called)
+}
+
+// Test that Catch can recover from or replace an error.
+func TestCatch(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+	errOther := fmt.Errorf("other")
+
+	fail := invoker.Task(func(ctx context.Context) (err error) {
+		return errSample
+	})
+
+	handle := func(err error) error {
+		if errors.Is(err, errSample) {
+			return nil
+		}
+
+		return err
+	}
+
+	err := invoker.Run(context.Background(), fail.Catch(handle))
+	require.NoError(err)
+
+	replace := func(err error) error {
+		return errOther
+	}
+
+	err = invoker.Run(context.Background(), fail.Catch(replace).Then(invoker.Noop))
+	require.Equal(errOther, err)
+
+	// The handler isn't called on success.
+	err = invoker.Run(context.Background(), invoker.Task(invoker.Noop).Catch(replace))
+	require.NoError(err)
+}
+
+// Test that Then doesn't start the next task once cancelled.
+func TestThenCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	first := invoker.Task(func(ctx context.Context) (err error) {
+		cancel()
+		return nil
+	})
+
+	called := false
+	next := func(ctx context.Context) (err error) {
+		called = true
+		return nil
+	}
+
+	err := invoker.Run(ctx, first.Then(next))
+	require.Equal(context.Canceled, err)
+	require.False(called)
+}
@@ -1,16 +1,136 @@
 package invoker
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrStopRequested is returned by StopOn when the channel fires.
+var ErrStopRequested = fmt.Errorf("stop requested")
 
 // Returns a Task that waits on the given context.
 // Thus, this can used to wait on two contexts.
+// The cause of the given context is returned, see context.WithCancelCause.
 func Context(ctx context.Context) Task {
 	return func(ctx2 context.Context) (err error) {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return context.Cause(ctx)
 		case <-ctx2.Done():
 			return ctx2.Err()
 		}
 	}
 }
+
+// Returns a Task that waits on any of the given contexts like Context, and a function reporting which one fired.
+// The function returns the index into others, or -1 if the task's own context fired or the task hasn't returned yet.
+// This is useful when contexts mean different things, ex. a user cancel versus a deadline.
+func ContextWhich(others ...context.Context) (t Task, which func() int) {
+	var fired atomic.Int64
+	fired.Store(-1)
+
+	t = func(ctx context.Context) (err error) {
+		cases := make([]reflect.SelectCase, 0, len(others)+1)
+		for _, other := range others {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(other.Done())})
+		}
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+
+		index, _, _ := reflect.Select(cases)
+		if index == len(others) {
+			return ctx.Err()
+		}
+
+		fired.Store(int64(index))
+		return context.Cause(others[index])
+	}
+
+	which = func() int {
+		return int(fired.Load())
+	}
+
+	return t, which
+}
+
+// Returns a Task that waits on the given context like Context, but returns nil instead of an error.
+func ContextNil(ctx context.Context) Task {
+	return func(ctx2 context.Context) (err error) {
+		select {
+		case <-ctx.Done():
+		case <-ctx2.Done():
+		}
+
+		return nil
+	}
+}
+
+// Returns a Task that waits on the given context like ContextNil, then runs the cleanup function and returns nil.
+// The cleanup also runs if the task's own context is done first, ex. to release a resource tied to a request.
+// The cleanup runs exactly once, even if the Task is run multiple times.
+func OnDone(watch context.Context, cleanup func()) Task {
+	var once sync.Once
+
+	return func(ctx context.Context) (err error) {
+		select {
+		case <-watch.Done():
+		case <-ctx.Done():
+		}
+
+		once.Do(cleanup)
+
+		return nil
+	}
+}
+
+// AfterFunc returns a Task that registers fn with context.AfterFunc, blocking until it has run and returning nil.
+// Unlike calling context.AfterFunc directly, the group doesn't finish until fn has returned, so it's never run afterwards.
+func AfterFunc(fn func()) Task {
+	return func(ctx context.Context) (err error) {
+		done := make(chan struct{})
+
+		stop := context.AfterFunc(ctx, func() {
+			defer close(done)
+			fn()
+		})
+		defer stop()
+
+		<-done
+
+		return nil
+	}
+}
+
+// CloseOnDone returns a Task that blocks until the context is done, then closes the channel and returns nil.
+// This ties closing a pipeline's output to the group lifecycle, so downstream consumers terminate cleanly.
+// The producer must have stopped sending before this runs, ex. by closing in a Defer or a later step of a sequence, otherwise it will panic.
+// The channel is closed exactly once, even if the Task is run multiple times.
+func CloseOnDone[T any](ch chan T) Task {
+	var once sync.Once
+
+	return func(ctx context.Context) (err error) {
+		<-ctx.Done()
+
+		once.Do(func() {
+			close(ch)
+		})
+
+		return nil
+	}
+}
+
+// StopOn returns a Task that returns ErrStopRequested when the channel receives or is closed, cancelling a Run group.
+// This is the inverse of Context: instead of the group waiting on an event, an event stops the group.
+// This allows arbitrary application events, ex. a feature flag flipping, to drive shutdown.
+func StopOn(ch <-chan struct{}) Task {
+	return func(ctx context.Context) (err error) {
+		select {
+		case <-ch:
+			return ErrStopRequested
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
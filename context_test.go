@@ -0,0 +1,230 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that Context returns when the other context is done.
+func TestContext(t *testing.T) {
+	require := require.New(t)
+
+	other, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(context.Background(), invoker.Context(other), invoker.Wait)
+	require.Equal(context.Canceled, err)
+}
+
+// Test that ContextNil returns nil when the other context is done.
+func TestContextNil(t *testing.T) {
+	require := require.New(t)
+
+	other, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(context.Background(), invoker.ContextNil(other))
+	require.NoError(err)
+}
+
+// Test that ContextWhich reports the index of the context that fired.
+func TestContextWhich(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	defer cancelFirst()
+
+	second, cancelSecond := context.WithCancelCause(context.Background())
+	cancelSecond(errSample)
+
+	task, which := invoker.ContextWhich(first, second)
+	require.Equal(-1, which())
+
+	err := invoker.Run(context.Background(), task)
+	require.Equal(errSample, err)
+	require.Equal(1, which())
+}
+
+// Test that ContextWhich reports -1 when the task's own context fired.
+func TestContextWhichOwn(t *testing.T) {
+	require := require.New(t)
+
+	other, cancelOther := context.WithCancel(context.Background())
+	defer cancelOther()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	task, which := invoker.ContextWhich(other)
+
+	err := invoker.Run(ctx, task)
+	require.Equal(context.Canceled, err)
+	require.Equal(-1, which())
+}
+
+// Test that OnDone runs the cleanup when the watched context is done.
+func TestOnDone(t *testing.T) {
+	require := require.New(t)
+
+	watch, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	count := 0
+	cleanup := func() {
+		count += 1
+	}
+
+	task := invoker.OnDone(watch, cleanup)
+
+	err := invoker.Run(context.Background(), task)
+	require.NoError(err)
+	require.Equal(1, count)
+
+	// The cleanup only runs once.
+	err = invoker.Run(context.Background(), task)
+	require.NoError(err)
+	require.Equal(1, count)
+}
+
+// Test that OnDone runs the cleanup when the task's own context is done.
+func TestOnDoneCancel(t *testing.T) {
+	require := require.New(t)
+
+	watch, cancelWatch := context.WithCancel(context.Background())
+	defer cancelWatch()
+
+	count := 0
+	cleanup := func() {
+		count += 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, invoker.OnDone(watch, cleanup))
+	require.NoError(err)
+	require.Equal(1, count)
+}
+
+// Test that AfterFunc runs the function once the context is cancelled, before the group finishes.
+func TestAfterFunc(t *testing.T) {
+	require := require.New(t)
+
+	count := uint64(0)
+	fn := func() {
+		time.Sleep(time.Millisecond)
+		atomic.AddUint64(&count, 1)
+	}
+
+	err := invoker.Run(context.Background(), invoker.AfterFunc(fn), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(uint64(1), atomic.LoadUint64(&count))
+
+	// The function is not called again after the task returns.
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(uint64(1), atomic.LoadUint64(&count))
+}
+
+// Test that CloseOnDone closes the channel once the group is cancelled.
+func TestCloseOnDone(t *testing.T) {
+	require := require.New(t)
+
+	ch := make(chan int, 1)
+	task := invoker.CloseOnDone(ch)
+
+	err := invoker.Run(context.Background(), task, invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+
+	_, ok := <-ch
+	require.False(ok)
+
+	// Running again doesn't close the channel twice.
+	require.NotPanics(func() {
+		err = invoker.Run(context.Background(), task, invoker.Timeout(time.Millisecond))
+	})
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that a consumer ranging over the channel terminates when the group is cancelled.
+func TestCloseOnDoneConsumer(t *testing.T) {
+	require := require.New(t)
+
+	ch := make(chan int)
+
+	count := 0
+	consumer := func(ctx context.Context) (err error) {
+		for range ch {
+			count += 1
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.CloseOnDone(ch), consumer, invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(0, count)
+}
+
+// Test that a group of WaitNil tasks returns nil when cancelled externally.
+func TestWaitNil(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+
+	err := invoker.Run(ctx, invoker.WaitNil, invoker.WaitNil, invoker.ContextNil(context.Background()))
+	require.NoError(err)
+}
+
+// Test that Context returns the cause of the other context.
+func TestContextCause(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	other, cancel := context.WithCancelCause(context.Background())
+	cancel(errSample)
+
+	err := invoker.Run(context.Background(), invoker.Context(other), invoker.Wait)
+	require.Equal(errSample, err)
+}
+
+// Test that closing the channel stops the group.
+func TestStopOn(t *testing.T) {
+	require := require.New(t)
+
+	stop := make(chan struct{})
+
+	errs := make(chan error, 1)
+	waiter := func(ctx context.Context) (err error) {
+		err = invoker.Wait(ctx)
+		errs <- err
+
+		return err
+	}
+
+	close(stop)
+
+	err := invoker.Run(context.Background(), invoker.StopOn(stop), waiter)
+	require.Equal(invoker.ErrStopRequested, err)
+	require.Equal(context.Canceled, <-errs)
+}
+
+// Test that StopOn returns on cancel if the channel never fires.
+func TestStopOnCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, invoker.StopOn(make(chan struct{})))
+	require.Equal(context.Canceled, err)
+}
@@ -0,0 +1,45 @@
+package invoker
+
+import (
+	"context"
+	"time"
+)
+
+// Pinger is implemented by *sql.DB from database/sql.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// pingBackoff is the maximum multiple of the interval to wait between pings.
+const pingBackoff = 16
+
+// PingDB returns a Task that pings the database until it succeeds, returning nil.
+// The wait between failed pings starts at the interval and doubles, up to 16 times the interval.
+// Each ping is limited to the current wait, so a hung connection doesn't block the retries.
+func PingDB(db Pinger, interval time.Duration) (t Task) {
+	return func(ctx context.Context) (err error) {
+		delay := interval
+
+		for {
+			err = ping(ctx, db, delay)
+			if err == nil {
+				return nil
+			}
+
+			err = Timer(delay)(ctx)
+			if err != nil {
+				return err
+			}
+
+			delay = min(2*delay, pingBackoff*interval)
+		}
+	}
+}
+
+// ping calls PingContext with a timeout.
+func ping(ctx context.Context, db Pinger, timeout time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return db.PingContext(ctx)
+}
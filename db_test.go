@@ -0,0 +1,68 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDB fails the given number of pings before succeeding.
+type fakeDB struct {
+	failures int
+	pings    int
+	deadline bool
+}
+
+func (db *fakeDB) PingContext(ctx context.Context) (err error) {
+	db.pings += 1
+
+	_, ok := ctx.Deadline()
+	db.deadline = ok
+
+	if db.pings <= db.failures {
+		return fmt.Errorf("connection refused")
+	}
+
+	return nil
+}
+
+// Test that PingDB retries until the ping succeeds.
+func TestPingDB(t *testing.T) {
+	require := require.New(t)
+
+	db := &fakeDB{failures: 3}
+
+	err := invoker.Run(context.Background(), invoker.PingDB(db, time.Millisecond))
+	require.NoError(err)
+	require.Equal(4, db.pings)
+	require.True(db.deadline)
+}
+
+// Test that PingDB backs off between failed pings.
+func TestPingDBBackoff(t *testing.T) {
+	require := require.New(t)
+
+	db := &fakeDB{failures: 3}
+
+	start := time.Now()
+
+	err := invoker.Run(context.Background(), invoker.PingDB(db, 5*time.Millisecond))
+	require.NoError(err)
+
+	// 5ms + 10ms + 20ms
+	require.True(time.Since(start) >= 35*time.Millisecond)
+}
+
+// Test that PingDB returns the context error when cancelled.
+func TestPingDBCancel(t *testing.T) {
+	require := require.New(t)
+
+	db := &fakeDB{failures: 1000}
+
+	err := invoker.Run(context.Background(), invoker.PingDB(db, time.Millisecond), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
@@ -0,0 +1,32 @@
+package invoker
+
+// Defer registers a function to run once Run/Race/Repeat has finished, regardless of the result.
+// Like the defer keyword, functions are run in the reverse order they were registered.
+// They are run after every task has returned, so resources opened last are closed first.
+// They are also run before Done is closed, so the resources are released by the time Wait returns.
+// If the group has already finished, the function is run immediately.
+func (ts *Tasks) Defer(cleanup func()) {
+	ts.mutex.Lock()
+
+	if ts.mode == modeDone {
+		ts.mutex.Unlock()
+		cleanup()
+
+		return
+	}
+
+	ts.deferred = append(ts.deferred, cleanup)
+	ts.mutex.Unlock()
+}
+
+// cleanup runs the deferred functions in reverse order.
+func (ts *Tasks) cleanup() {
+	ts.mutex.Lock()
+	deferred := ts.deferred
+	ts.deferred = nil
+	ts.mutex.Unlock()
+
+	for i := len(deferred) - 1; i >= 0; i -= 1 {
+		deferred[i]()
+	}
+}
@@ -0,0 +1,106 @@
+package invoker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that deferred functions run in reverse order after success.
+func TestDefer(t *testing.T) {
+	require := require.New(t)
+
+	var order []int
+
+	tasks := invoker.New()
+	for i := 0; i < 3; i += 1 {
+		i := i
+		tasks.Add(func(ctx context.Context) (err error) {
+			tasks.Defer(func() {
+				order = append(order, i)
+			})
+
+			return nil
+		})
+	}
+
+	// Registered before any task, so it runs last.
+	tasks.Defer(func() {
+		order = append(order, -1)
+	})
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Len(order, 4)
+	require.Equal(-1, order[3])
+}
+
+// Test that deferred functions run after cancellation, once every task has returned.
+func TestDeferCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var order []string
+
+	tasks := invoker.New(func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		order = append(order, "task")
+
+		return ctx.Err()
+	})
+
+	tasks.Defer(func() {
+		order = append(order, "first")
+	})
+
+	tasks.Defer(func() {
+		order = append(order, "second")
+	})
+
+	cancel()
+
+	err := tasks.Run(ctx)
+	require.Equal(context.Canceled, err)
+	require.Equal([]string{"task", "second", "first"}, order)
+}
+
+// Test that a function deferred after finishing is run immediately.
+func TestDeferFinished(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+	require.NoError(tasks.Run(context.Background()))
+
+	called := false
+	tasks.Defer(func() {
+		called = true
+	})
+
+	require.True(called)
+}
+
+// Test that the deferred functions have run by the time Wait returns.
+func TestDeferWait(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New(invoker.Noop)
+
+	cleaned := uint64(0)
+	tasks.Defer(func() {
+		time.Sleep(5 * time.Millisecond)
+		atomic.StoreUint64(&cleaned, 1)
+	})
+
+	go func() {
+		_ = tasks.Run(context.Background())
+	}()
+
+	err := tasks.Wait()
+	require.NoError(err)
+	require.Equal(uint64(1), atomic.LoadUint64(&cleaned))
+}
@@ -0,0 +1,92 @@
+package invoker
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Balancer chooses which handler receives the next item, given the number of items each handler is busy with.
+// It's only called from a single goroutine.
+type Balancer func(busy []int) (index int)
+
+// RoundRobin returns a Balancer that chooses each handler in turn.
+func RoundRobin() Balancer {
+	next := 0
+
+	return func(busy []int) (index int) {
+		index = next % len(busy)
+		next = index + 1
+		return index
+	}
+}
+
+// LeastBusy is a Balancer that chooses the handler with the fewest items, preferring the first on a tie.
+func LeastBusy(busy []int) (index int) {
+	for i, n := range busy {
+		if n < busy[index] {
+			index = i
+		}
+	}
+
+	return index
+}
+
+// Dispatch returns a Task that distributes the values received on the channel across the handlers using RoundRobin.
+// See DispatchWith for details.
+func Dispatch[T any](in <-chan T, handlers []func(ctx context.Context, v T) (err error)) (t Task) {
+	return DispatchWith(in, handlers, RoundRobin())
+}
+
+// DispatchWith returns a Task that distributes the values received on the channel across the handlers, chosen by the balancer.
+// Each handler runs concurrently but processes a single value at a time, so dispatching waits until the chosen handler is free.
+// It returns the first handler error, cancelling the others, or nil once the channel is closed and every value is handled.
+// At least one handler is required.
+func DispatchWith[T any](in <-chan T, handlers []func(ctx context.Context, v T) (err error), balance Balancer) (t Task) {
+	return func(ctx context.Context) (err error) {
+		inboxes := make([]chan T, len(handlers))
+		busy := make([]atomic.Int64, len(handlers))
+
+		ts := New()
+
+		for i, handler := range handlers {
+			i, handler := i, handler
+			inboxes[i] = make(chan T)
+
+			ts.Add(func(ctx context.Context) (err error) {
+				return Listen(inboxes[i], func(ctx context.Context, v T) (err error) {
+					defer busy[i].Add(-1)
+					return handler(ctx, v)
+				})(ctx)
+			})
+		}
+
+		ts.Add(func(ctx context.Context) (err error) {
+			// Closing the inboxes stops each handler once it's done.
+			defer func() {
+				for _, inbox := range inboxes {
+					close(inbox)
+				}
+			}()
+
+			counts := make([]int, len(handlers))
+
+			return Listen(in, func(ctx context.Context, v T) (err error) {
+				for i := range busy {
+					counts[i] = int(busy[i].Load())
+				}
+
+				i := balance(counts)
+				busy[i].Add(1)
+
+				select {
+				case inboxes[i] <- v:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})(ctx)
+		})
+
+		return ts.Run(ctx)
+	}
+}
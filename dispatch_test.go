@@ -0,0 +1,117 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// counting returns handlers that count the values they receive.
+func counting(counts []uint64) []func(ctx context.Context, v int) (err error) {
+	handlers := make([]func(ctx context.Context, v int) (err error), len(counts))
+
+	for i := range handlers {
+		i := i
+
+		handlers[i] = func(ctx context.Context, v int) (err error) {
+			atomic.AddUint64(&counts[i], 1)
+			return nil
+		}
+	}
+
+	return handlers
+}
+
+// Test that values are spread evenly across the handlers.
+func TestDispatch(t *testing.T) {
+	require := require.New(t)
+
+	in := make(chan int, 9)
+	for i := 0; i < 9; i += 1 {
+		in <- i
+	}
+	close(in)
+
+	counts := make([]uint64, 3)
+
+	err := invoker.Run(context.Background(), invoker.Dispatch(in, counting(counts)))
+	require.NoError(err)
+	require.Equal([]uint64{3, 3, 3}, counts)
+}
+
+// Test that a handler error stops processing.
+func TestDispatchError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	in := make(chan int)
+	counts := make([]uint64, 2)
+	handlers := counting(counts)
+
+	handlers[1] = func(ctx context.Context, v int) (err error) {
+		return errSample
+	}
+
+	produce := func(ctx context.Context) (err error) {
+		for i := 0; ; i += 1 {
+			select {
+			case in <- i:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	err := invoker.Run(context.Background(), invoker.Dispatch(in, handlers), produce)
+	require.Equal(errSample, err)
+}
+
+// Test that RoundRobin cycles through each index.
+func TestRoundRobin(t *testing.T) {
+	require := require.New(t)
+
+	balance := invoker.RoundRobin()
+	busy := make([]int, 3)
+
+	var order []int
+	for i := 0; i < 5; i += 1 {
+		order = append(order, balance(busy))
+	}
+
+	require.Equal([]int{0, 1, 2, 0, 1}, order)
+}
+
+// Test that LeastBusy chooses the first handler with the fewest values.
+func TestLeastBusy(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal(1, invoker.LeastBusy([]int{2, 0, 1}))
+	require.Equal(0, invoker.LeastBusy([]int{1, 1, 1}))
+	require.Equal(2, invoker.LeastBusy([]int{3, 1, 0}))
+}
+
+// Test that a custom balancer can route every value to one handler.
+func TestDispatchWith(t *testing.T) {
+	require := require.New(t)
+
+	in := make(chan int, 4)
+	for i := 0; i < 4; i += 1 {
+		in <- i
+	}
+	close(in)
+
+	counts := make([]uint64, 3)
+
+	last := func(busy []int) (index int) {
+		return len(busy) - 1
+	}
+
+	err := invoker.Run(context.Background(), invoker.DispatchWith(in, counting(counts), last))
+	require.NoError(err)
+	require.Equal([]uint64{0, 0, 4}, counts)
+}
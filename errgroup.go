@@ -0,0 +1,35 @@
+package invoker
+
+import "context"
+
+// Waiter is implemented by *errgroup.Group from golang.org/x/sync/errgroup.
+type Waiter interface {
+	Wait() error
+}
+
+// FromErrGroup returns a Task that blocks until the errgroup has finished, returning the result of Wait.
+// The errgroup is not cancelled when the context is done; use errgroup.WithContext and cancel the parent instead.
+// NOTE: Wait is not cancelable, so a goroutine will keep waiting after the context is done.
+func FromErrGroup(g Waiter) (t Task) {
+	return func(ctx context.Context) (err error) {
+		done := make(chan error, 1)
+
+		go func() {
+			done <- g.Wait()
+		}()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err = <-done:
+			return err
+		}
+	}
+}
+
+// ToErrGroup returns a function for errgroup.Group.Go that runs the task with the given context.
+func ToErrGroup(ctx context.Context, t Task) func() error {
+	return func() error {
+		return t(ctx)
+	}
+}
@@ -0,0 +1,73 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// group has the same API as errgroup.Group to avoid the dependency.
+type group struct {
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+}
+
+func (g *group) Go(f func() error) {
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		err := f()
+		if err != nil {
+			g.once.Do(func() {
+				g.err = err
+			})
+		}
+	}()
+}
+
+func (g *group) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+// Test that an errgroup error is returned by the task.
+func TestFromErrGroup(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+	ctx := context.Background()
+
+	var g group
+	g.Go(invoker.ToErrGroup(ctx, invoker.Noop))
+	g.Go(func() error {
+		return errSample
+	})
+
+	err := invoker.Run(ctx, invoker.FromErrGroup(&g), invoker.Wait)
+	require.Equal(errSample, err)
+}
+
+// Test that the task returns on cancel even if the errgroup is still running.
+func TestFromErrGroupCancel(t *testing.T) {
+	require := require.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	var g group
+	g.Go(func() error {
+		<-release
+		return nil
+	})
+
+	err := invoker.Run(context.Background(), invoker.FromErrGroup(&g), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
@@ -0,0 +1,123 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// WithTaskErrors wraps any error returned by a task in a TaskError, identifying which task failed.
+func WithTaskErrors() Option {
+	return func(ts *Tasks) {
+		ts.taskErrors = true
+	}
+}
+
+// WithLowestIndexError makes Run/Repeat return the error from the lowest index task, instead of the first error reported.
+// This makes the result reproducible when multiple tasks fail at the same time.
+// Cancellation errors are ignored once the group has been cancelled, as they were caused by another error.
+// The first error is still used to cancel the remaining tasks.
+func WithLowestIndexError() Option {
+	return func(ts *Tasks) {
+		ts.lowestError = true
+	}
+}
+
+// WithRootCause makes Run/Repeat return the most useful error when the context is cancelled externally.
+// Any task error that isn't a cancellation is preferred, even if it was reported after a cancellation error.
+// Otherwise the cause of the parent context is returned, see context.WithCancelCause.
+func WithRootCause() Option {
+	return func(ts *Tasks) {
+		ts.rootCause = true
+	}
+}
+
+// isCancel returns true if the error was caused by a context being done.
+func isCancel(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isPanic returns true if the error is an ErrPanic.
+func isPanic(err error) bool {
+	var ep ErrPanic
+	return errors.As(err, &ep)
+}
+
+// isSignal returns true if the error is an ErrSignal.
+func isSignal(err error) bool {
+	var es ErrSignal
+	return errors.As(err, &es)
+}
+
+// externalCause returns the cause of the parent or base context if either was cancelled.
+// Otherwise the error is returned unchanged.
+func (ts *Tasks) externalCause(parent context.Context, err error) error {
+	if parent.Err() != nil {
+		return context.Cause(parent)
+	}
+
+	if ts.base != nil && ts.base.Err() != nil {
+		return context.Cause(ts.base)
+	}
+
+	return err
+}
+
+// recordLowest keeps the error if it came from the lowest index so far.
+// The mutex must be held.
+func (ts *Tasks) recordLowest(index int, err error) {
+	if !ts.lowestError || err == nil {
+		return
+	}
+
+	if errors.Is(err, context.Canceled) && ts.ctx != nil && ts.ctx.Err() != nil {
+		return
+	}
+
+	if ts.lowest == nil || index < ts.lowestIndex {
+		ts.lowest = err
+		ts.lowestIndex = index
+	}
+}
+
+// TaskError is returned when configured by WithTaskErrors.
+type TaskError struct {
+	// The order the task was added, starting at zero.
+	Index int
+
+	// The error returned by the task.
+	Err error
+}
+
+func (te TaskError) Error() string {
+	return fmt.Sprintf("task %d: %s", te.Index, te.Err)
+}
+
+func (te TaskError) Unwrap() error {
+	return te.Err
+}
+
+// MultiError is returned by RunAll with every task that failed.
+type MultiError struct {
+	errs []error
+}
+
+// Errors returns a TaskError for each task that failed, in the order the tasks were added.
+func (me MultiError) Errors() []error {
+	return me.errs
+}
+
+func (me MultiError) Error() string {
+	msgs := make([]string, len(me.errs))
+	for i, err := range me.errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap allows errors.Is and errors.As to match any of the errors.
+func (me MultiError) Unwrap() []error {
+	return me.errs
+}
@@ -0,0 +1,197 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the error identifies the task that failed.
+func TestTaskErrors(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	tasks := invoker.New(invoker.Wait, invoker.Wait, fail).Apply(invoker.WithTaskErrors())
+
+	err := tasks.Run(context.Background())
+	require.True(errors.Is(err, errSample))
+
+	var te invoker.TaskError
+	require.True(errors.As(err, &te))
+	require.Equal(2, te.Index)
+	require.Equal(errSample, te.Err)
+}
+
+// Test that errors are not wrapped by default.
+func TestTaskErrorsDefault(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.Wait, fail)
+	require.Equal(errSample, err)
+}
+
+// Test that the lowest index error is returned regardless of scheduling.
+func TestLowestIndexError(t *testing.T) {
+	require := require.New(t)
+
+	for i := 0; i < 100; i += 1 {
+		tasks := invoker.New().Apply(invoker.WithLowestIndexError())
+
+		for j := 0; j < 5; j += 1 {
+			errSample := fmt.Errorf("error %d", j)
+			tasks.Add(func(ctx context.Context) (err error) {
+				return errSample
+			})
+		}
+
+		err := tasks.Run(context.Background())
+		require.EqualError(err, "error 0")
+		require.EqualError(tasks.Err(), "error 0")
+	}
+}
+
+// Test that cancellation caused by another task's error is ignored.
+func TestLowestIndexErrorCancel(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	tasks := invoker.New(invoker.Wait, func(ctx context.Context) (err error) {
+		return errSample
+	}).Apply(invoker.WithLowestIndexError())
+
+	err := tasks.Run(context.Background())
+	require.Equal(errSample, err)
+}
+
+// Test that RunAll runs every task and returns each failure in order.
+func TestRunAll(t *testing.T) {
+	require := require.New(t)
+
+	errFirst := fmt.Errorf("first")
+	errSecond := fmt.Errorf("second")
+
+	fail := func(err error) invoker.Task {
+		return func(ctx context.Context) error {
+			return err
+		}
+	}
+
+	err := invoker.RunAll(context.Background(), fail(nil), fail(errFirst), fail(nil), fail(errSecond))
+	require.IsType(invoker.MultiError{}, err)
+
+	errs := err.(invoker.MultiError).Errors()
+	require.Len(errs, 2)
+	require.Equal(invoker.TaskError{Index: 1, Err: errFirst}, errs[0])
+	require.Equal(invoker.TaskError{Index: 3, Err: errSecond}, errs[1])
+
+	require.True(errors.Is(err, errFirst))
+	require.True(errors.Is(err, errSecond))
+	require.Equal("task 1: first\ntask 3: second", err.Error())
+
+	var te invoker.TaskError
+	require.True(errors.As(err, &te))
+	require.Equal(1, te.Index)
+}
+
+// Test that RunAll returns nil if every task succeeded.
+func TestRunAllSuccess(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.RunAll(context.Background(), invoker.Noop, invoker.Noop)
+	require.NoError(err)
+}
+
+// Test that a task error is preferred over an external cancel, regardless of scheduling.
+func TestRootCause(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	for i := 0; i < 100; i += 1 {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		fail := func(ctx context.Context) (err error) {
+			cancel()
+			return errSample
+		}
+
+		tasks := invoker.New(invoker.Wait, invoker.Wait, fail).Apply(invoker.WithRootCause())
+
+		err := tasks.Run(ctx)
+		require.Equal(errSample, err)
+
+		cancel()
+	}
+}
+
+// Test that the parent's cause is returned when no task failed.
+func TestRootCauseParent(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errSample)
+
+	tasks := invoker.New(invoker.Wait).Apply(invoker.WithRootCause())
+
+	err := tasks.Run(ctx)
+	require.Equal(errSample, err)
+}
+
+// Test that panics are recorded without stopping the other tasks.
+func TestRunResilient(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	done := make([]bool, 3)
+	work := func(i int) invoker.Task {
+		return func(ctx context.Context) (err error) {
+			// Give the failures a chance to cancel us.
+			time.Sleep(time.Millisecond)
+			done[i] = ctx.Err() == nil
+
+			return nil
+		}
+	}
+
+	panicky := func(ctx context.Context) (err error) {
+		panic("oops")
+	}
+
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	err := invoker.RunResilient(context.Background(), work(0), panicky, work(1), fail, work(2))
+	require.IsType(invoker.MultiError{}, err)
+	require.Equal([]bool{true, true, true}, done)
+
+	errs := err.(invoker.MultiError).Errors()
+	require.Len(errs, 2)
+
+	var te invoker.TaskError
+	require.True(errors.As(errs[0], &te))
+	require.Equal(1, te.Index)
+	require.IsType(invoker.ErrPanic{}, te.Err)
+
+	require.Equal(invoker.TaskError{Index: 3, Err: errSample}, errs[1])
+}
@@ -0,0 +1,153 @@
+package invoker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Command returns a Task that starts the process and waits for it to exit, returning the result of Wait.
+// On cancel, the process is sent SIGTERM and then killed if it hasn't exited after the grace period.
+func Command(cmd *exec.Cmd, grace time.Duration) (t Task) {
+	return func(ctx context.Context) (err error) {
+		err = cmd.Start()
+		if err != nil {
+			return err
+		}
+
+		done := make(chan error, 1)
+
+		go func() {
+			done <- cmd.Wait()
+		}()
+
+		select {
+		case err = <-done:
+			return err
+		case <-ctx.Done():
+		}
+
+		// Ask nicely first, ignoring errors because the process may have just exited.
+		_ = cmd.Process.Signal(syscall.SIGTERM)
+
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+		case <-timer.C:
+			_ = cmd.Process.Kill()
+			<-done
+		}
+
+		return ctx.Err()
+	}
+}
+
+// CommandOutput returns a Task like Command, but streams the process's stdout and stderr to the writers.
+// The output is copied until the process exits, and every write has finished before the Task returns.
+// If the output is still open after the process exits, ex. held by a child process, it's closed after the grace period.
+func CommandOutput(cmd *exec.Cmd, stdout io.Writer, stderr io.Writer, grace time.Duration) (t Task) {
+	// Wait will copy the output in goroutines and wait for them to finish.
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = grace
+
+	return Command(cmd, grace)
+}
+
+// Processes returns a Task that runs each named process like CommandOutput, ex. as a development process manager.
+// Each line of a process's combined stdout and stderr is prefixed with its name before being written to the shared writer.
+// The lines are written whole, so output from different processes is interleaved by line and never within a line.
+// Like Run, the first process to exit with an error cancels the rest, while a clean exit doesn't.
+func Processes(w io.Writer, grace time.Duration, cmds map[string]*exec.Cmd) (t Task) {
+	names := make([]string, 0, len(cmds))
+	width := 0
+
+	for name := range cmds {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+
+	sort.Strings(names)
+
+	var mutex sync.Mutex
+
+	tasks := make([]Task, 0, len(names))
+	for _, name := range names {
+		pw := &prefixWriter{
+			w:      w,
+			mutex:  &mutex,
+			prefix: fmt.Sprintf("%-*s | ", width, name),
+		}
+
+		// The same writer for both means only one goroutine writes at a time, see exec.Cmd.
+		run := CommandOutput(cmds[name], pw, pw, grace)
+
+		tasks = append(tasks, func(ctx context.Context) (err error) {
+			defer pw.flush()
+			return run(ctx)
+		})
+	}
+
+	return func(ctx context.Context) (err error) {
+		return Run(ctx, tasks...)
+	}
+}
+
+// prefixWriter writes each complete line to w with the prefix, buffering any partial line.
+type prefixWriter struct {
+	w      io.Writer
+	mutex  *sync.Mutex
+	prefix string
+
+	// the partial line since the last newline
+	partial []byte
+}
+
+func (pw *prefixWriter) Write(p []byte) (n int, err error) {
+	pw.partial = append(pw.partial, p...)
+
+	for {
+		i := bytes.IndexByte(pw.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		err = pw.line(pw.partial[:i+1])
+		pw.partial = pw.partial[i+1:]
+
+		if err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// flush writes any partial line, with a newline added, once the process has exited.
+func (pw *prefixWriter) flush() {
+	if len(pw.partial) == 0 {
+		return
+	}
+
+	_ = pw.line(append(pw.partial, '\n'))
+	pw.partial = nil
+}
+
+// line writes the prefix and line with a single call, holding the shared mutex.
+func (pw *prefixWriter) line(line []byte) (err error) {
+	buf := make([]byte, 0, len(pw.prefix)+len(line))
+	buf = append(buf, pw.prefix...)
+	buf = append(buf, line...)
+
+	pw.mutex.Lock()
+	defer pw.mutex.Unlock()
+
+	_, err = pw.w.Write(buf)
+	return err
+}
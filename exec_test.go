@@ -0,0 +1,129 @@
+package invoker_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the exit status is returned.
+func TestCommand(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.Command(exec.Command("true"), time.Second))
+	require.NoError(err)
+
+	err = invoker.Run(context.Background(), invoker.Command(exec.Command("false"), time.Second))
+	require.IsType(&exec.ExitError{}, err)
+}
+
+// Test that cancel terminates the process.
+func TestCommandCancel(t *testing.T) {
+	require := require.New(t)
+
+	cmd := exec.Command("sleep", "10")
+
+	start := time.Now()
+
+	err := invoker.Run(context.Background(), invoker.Command(cmd, time.Minute), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.True(time.Since(start) < 5*time.Second)
+	require.False(cmd.ProcessState.Success())
+}
+
+// Test that a process ignoring SIGTERM is killed after the grace period.
+func TestCommandKill(t *testing.T) {
+	require := require.New(t)
+
+	cmd := exec.Command("sh", "-c", "trap '' TERM; sleep 10")
+
+	start := time.Now()
+
+	err := invoker.Run(context.Background(), invoker.Command(cmd, 50*time.Millisecond), invoker.Timeout(50*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.True(time.Since(start) < 5*time.Second)
+}
+
+// Test that the output is captured before the task returns.
+func TestCommandOutput(t *testing.T) {
+	require := require.New(t)
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("sh", "-c", "echo hello; echo world >&2")
+
+	err := invoker.Run(context.Background(), invoker.CommandOutput(cmd, &stdout, &stderr, time.Second))
+	require.NoError(err)
+	require.Equal("hello\n", stdout.String())
+	require.Equal("world\n", stderr.String())
+}
+
+// Test that output written before cancel is captured.
+func TestCommandOutputCancel(t *testing.T) {
+	require := require.New(t)
+
+	var stdout bytes.Buffer
+
+	// Print and then wait to be terminated.
+	cmd := exec.Command("sh", "-c", "echo hello; exec sleep 10")
+
+	err := invoker.Run(context.Background(), invoker.CommandOutput(cmd, &stdout, io.Discard, time.Minute), invoker.Timeout(100*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal("hello\n", stdout.String())
+}
+
+// Test that the output of each process is prefixed with its name, line by line.
+func TestProcesses(t *testing.T) {
+	require := require.New(t)
+
+	var out bytes.Buffer
+
+	cmds := map[string]*exec.Cmd{
+		"web":    exec.Command("sh", "-c", "for i in 1 2 3; do echo web $i; sleep 0.01; done"),
+		"worker": exec.Command("sh", "-c", "for i in 1 2 3; do echo worker $i >&2; sleep 0.01; done; printf partial"),
+	}
+
+	err := invoker.Run(context.Background(), invoker.Processes(&out, time.Second, cmds))
+	require.NoError(err)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.ElementsMatch([]string{
+		"web    | web 1",
+		"web    | web 2",
+		"web    | web 3",
+		"worker | worker 1",
+		"worker | worker 2",
+		"worker | worker 3",
+		"worker | partial",
+	}, lines)
+}
+
+// Test that a process exiting with an error stops the others.
+func TestProcessesError(t *testing.T) {
+	require := require.New(t)
+
+	var out bytes.Buffer
+
+	sleep := exec.Command("sleep", "10")
+
+	cmds := map[string]*exec.Cmd{
+		"fail":  exec.Command("sh", "-c", "echo bye; exit 3"),
+		"sleep": sleep,
+	}
+
+	start := time.Now()
+
+	err := invoker.Run(context.Background(), invoker.Processes(&out, time.Second, cmds))
+	require.IsType(&exec.ExitError{}, err)
+	require.Equal(3, err.(*exec.ExitError).ExitCode())
+	require.True(time.Since(start) < 5*time.Second)
+	require.False(sleep.ProcessState.Success())
+	require.Equal("fail  | bye\n", out.String())
+}
@@ -0,0 +1,82 @@
+package invoker
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+)
+
+// expvarMutex serializes looking up and publishing the maps, since expvar.NewMap panics if the name is taken.
+var expvarMutex sync.Mutex
+
+// WithExpvar publishes counters for the tasks as an expvar.Map with the given name.
+// The counters are: started, succeeded, failed, panicked, and active.
+// A failed task is also counted as panicked if it returned an ErrPanic, see Recover.
+// Groups configured with the same name will share the same counters.
+// If the name is already published as something other than an expvar.Map, the counters are kept but not published.
+func WithExpvar(name string) Option {
+	vars := publishMap(name)
+
+	return func(ts *Tasks) {
+		ts.vars = vars
+	}
+}
+
+// publishMap returns the expvar.Map with the given name, publishing a new one if the name is unused.
+func publishMap(name string) (vars *expvar.Map) {
+	expvarMutex.Lock()
+	defer expvarMutex.Unlock()
+
+	existing := expvar.Get(name)
+	if existing == nil {
+		return expvar.NewMap(name)
+	}
+
+	vars, ok := existing.(*expvar.Map)
+	if !ok {
+		// Don't panic over metrics; count into a private map instead.
+		vars = new(expvar.Map).Init()
+	}
+
+	return vars
+}
+
+// varsStart updates the counters when a task is started.
+func (ts *Tasks) varsStart() {
+	if ts.vars == nil {
+		return
+	}
+
+	ts.vars.Add("started", 1)
+	ts.vars.Add("active", 1)
+}
+
+// varsFinish updates the counters when a task has returned.
+func (ts *Tasks) varsFinish(err error) {
+	if ts.vars == nil {
+		return
+	}
+
+	ts.vars.Add("active", -1)
+
+	if err == nil {
+		ts.vars.Add("succeeded", 1)
+		return
+	}
+
+	ts.vars.Add("failed", 1)
+
+	var ep ErrPanic
+	if errors.As(err, &ep) {
+		ts.vars.Add("panicked", 1)
+	}
+}
+
+// varsSkip updates the counters when a task was cancelled by its handle, see TaskHandle.
+func (ts *Tasks) varsSkip() {
+	if ts.vars == nil {
+		return
+	}
+
+	ts.vars.Add("active", -1)
+}
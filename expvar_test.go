@@ -0,0 +1,82 @@
+package invoker_test
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the counters are published after a run.
+func TestExpvar(t *testing.T) {
+	require := require.New(t)
+
+	f := func(ctx context.Context) (err error) {
+		return nil
+	}
+
+	fail := func(ctx context.Context) (err error) {
+		return fmt.Errorf("hello")
+	}
+
+	panicky := invoker.Recover(func(ctx context.Context) (err error) {
+		panic("hello")
+	})
+
+	tasks := invoker.New(f, f, fail, panicky).Apply(invoker.WithExpvar("invoker_test"))
+
+	// Reset the counters in case the test is run multiple times.
+	vars := expvar.Get("invoker_test").(*expvar.Map)
+	vars.Init()
+
+	err := tasks.Run(context.Background())
+	require.Error(err)
+
+	require.Equal("4", vars.Get("started").String())
+	require.Equal("2", vars.Get("succeeded").String())
+	require.Equal("2", vars.Get("failed").String())
+	require.Equal("1", vars.Get("panicked").String())
+	require.Equal("0", vars.Get("active").String())
+}
+
+// Test that concurrent groups with the same name share the counters without panicking.
+func TestExpvarConcurrent(t *testing.T) {
+	require := require.New(t)
+
+	f := func(ctx context.Context) (err error) {
+		return nil
+	}
+
+	tasks := invoker.New()
+	for i := 0; i < 8; i += 1 {
+		tasks.Add(func(ctx context.Context) (err error) {
+			return invoker.New(f).Apply(invoker.WithExpvar("invoker_test_concurrent")).Run(ctx)
+		})
+	}
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+
+	vars := expvar.Get("invoker_test_concurrent").(*expvar.Map)
+	require.NotNil(vars.Get("started"))
+}
+
+// Test that a name already published as another type doesn't panic.
+func TestExpvarConflict(t *testing.T) {
+	require := require.New(t)
+
+	if expvar.Get("invoker_test_conflict") == nil {
+		expvar.NewString("invoker_test_conflict")
+	}
+
+	f := func(ctx context.Context) (err error) {
+		return nil
+	}
+
+	err := invoker.New(f).Apply(invoker.WithExpvar("invoker_test_conflict")).Run(context.Background())
+	require.NoError(err)
+	require.IsType(&expvar.String{}, expvar.Get("invoker_test_conflict"))
+}
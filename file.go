@@ -0,0 +1,130 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// WatchFile returns a Task that calls onChange each time the file is modified, returning the first error.
+// The file is polled at the given interval, so any changes between polls are coalesced into a single call.
+// Creating or removing the file also counts as a modification.
+func WatchFile(path string, interval time.Duration, onChange func(ctx context.Context) (err error)) (t Task) {
+	return func(ctx context.Context) (err error) {
+		last, err := stat(path)
+		if err != nil {
+			return err
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+
+			current, err := stat(path)
+			if err != nil {
+				return err
+			}
+
+			if current == last {
+				continue
+			}
+
+			last = current
+
+			err = onChange(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// WatchFiles returns a Task that calls onChange when any of the files are modified, returning the first error.
+// The files are polled every debounce interval, and onChange is called once a full interval passes without any further changes.
+// Thus a burst of changes across multiple files results in a single call.
+// Files are polled by path, so an editor replacing a file via rename is still detected.
+func WatchFiles(paths []string, debounce time.Duration, onChange func(ctx context.Context) (err error)) (t Task) {
+	return func(ctx context.Context) (err error) {
+		last := make([]fileState, len(paths))
+		for i, path := range paths {
+			last[i], err = stat(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		ticker := time.NewTicker(debounce)
+		defer ticker.Stop()
+
+		// Set when a change was detected but onChange hasn't been called yet.
+		pending := false
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+
+			changed := false
+
+			for i, path := range paths {
+				current, err := stat(path)
+				if err != nil {
+					return err
+				}
+
+				if current != last[i] {
+					last[i] = current
+					changed = true
+				}
+			}
+
+			if changed {
+				// Wait for the burst to settle.
+				pending = true
+				continue
+			}
+
+			if !pending {
+				continue
+			}
+
+			pending = false
+
+			err = onChange(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// fileState is used to detect modifications to a file.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func stat(path string) (state fileState, err error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return state, err
+	}
+
+	return fileState{
+		exists:  true,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}, nil
+}
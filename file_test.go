@@ -0,0 +1,144 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that modifying a file calls onChange.
+func TestWatchFile(t *testing.T) {
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(os.WriteFile(path, []byte("hello"), 0o644))
+
+	errChanged := fmt.Errorf("changed")
+	onChange := func(ctx context.Context) (err error) {
+		return errChanged
+	}
+
+	modify := func(ctx context.Context) (err error) {
+		err = invoker.Sleep(10 * time.Millisecond)(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(path, []byte("hello world"), 0o644)
+		if err != nil {
+			return err
+		}
+
+		return invoker.Wait(ctx)
+	}
+
+	err := invoker.Run(context.Background(), invoker.WatchFile(path, time.Millisecond, onChange), modify)
+	require.Equal(errChanged, err)
+}
+
+// Test that an unmodified file doesn't call onChange.
+func TestWatchFileUnchanged(t *testing.T) {
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(os.WriteFile(path, []byte("hello"), 0o644))
+
+	onChange := func(ctx context.Context) (err error) {
+		return fmt.Errorf("changed")
+	}
+
+	err := invoker.Run(context.Background(), invoker.WatchFile(path, time.Millisecond, onChange), invoker.Timeout(20*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that a burst of changes to multiple files results in a single onChange.
+func TestWatchFiles(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+
+	paths := []string{
+		filepath.Join(dir, "a"),
+		filepath.Join(dir, "b"),
+		filepath.Join(dir, "c"),
+	}
+
+	for _, path := range paths {
+		require.NoError(os.WriteFile(path, []byte("hello"), 0o644))
+	}
+
+	count := uint64(0)
+	onChange := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	modify := func(ctx context.Context) (err error) {
+		err = invoker.Sleep(10 * time.Millisecond)(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths[:2] {
+			err = os.WriteFile(path, []byte("hello world"), 0o644)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Replace the last file via rename, like an editor.
+		tmp := filepath.Join(dir, "c.tmp")
+
+		err = os.WriteFile(tmp, []byte("hello world"), 0o644)
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(tmp, paths[2])
+		if err != nil {
+			return err
+		}
+
+		return invoker.Wait(ctx)
+	}
+
+	err := invoker.Run(context.Background(), invoker.WatchFiles(paths, 50*time.Millisecond, onChange), modify, invoker.Timeout(300*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(uint64(1), atomic.LoadUint64(&count))
+}
+
+// Test that an onChange error is returned.
+func TestWatchFilesError(t *testing.T) {
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "config")
+
+	errChanged := fmt.Errorf("changed")
+	onChange := func(ctx context.Context) (err error) {
+		return errChanged
+	}
+
+	create := func(ctx context.Context) (err error) {
+		err = invoker.Sleep(10 * time.Millisecond)(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(path, []byte("hello"), 0o644)
+		if err != nil {
+			return err
+		}
+
+		return invoker.Wait(ctx)
+	}
+
+	err := invoker.Run(context.Background(), invoker.WatchFiles([]string{path}, time.Millisecond, onChange), create)
+	require.Equal(errChanged, err)
+}
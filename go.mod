@@ -1,9 +1,14 @@
 module github.com/kixelated/invoker
 
-go 1.12
+go 1.21
 
 require (
 	github.com/kisielk/errcheck v1.4.0
 	github.com/stretchr/testify v1.3.0
 	honnef.co/go/tools v0.0.1-2020.1.6
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
@@ -0,0 +1,37 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+)
+
+// Group returns a Task that runs the group, allowing groups to be nested.
+// If the group was cancelled by the parent context, the cause of the cancellation is returned instead.
+// Otherwise the group's own first error is returned.
+func Group(ts *Tasks) (t Task) {
+	return func(ctx context.Context) (err error) {
+		err = ts.Run(ctx)
+		if err == nil || ctx.Err() == nil {
+			return err
+		}
+
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return context.Cause(ctx)
+		}
+
+		return err
+	}
+}
+
+// UntilDone returns a Task that waits until the other group has finished, returning nil.
+// This allows one group's shutdown to gate another without sharing a context.
+func UntilDone(other *Tasks) (t Task) {
+	return func(ctx context.Context) (err error) {
+		select {
+		case <-other.Done():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the cause of a cancelled parent is returned through two levels of groups.
+func TestGroupCause(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	inner := invoker.New(func(ctx context.Context) (err error) {
+		cancel(errSample)
+		return invoker.Wait(ctx)
+	})
+
+	middle := invoker.New(invoker.Group(inner))
+
+	err := invoker.Run(ctx, invoker.Group(middle))
+	require.Equal(errSample, err)
+}
+
+// Test that a sibling error is visible as the cause to a nested group.
+func TestGroupSibling(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	inner := invoker.New(invoker.Wait)
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	errs := make(chan error, 1)
+	nested := func(ctx context.Context) (err error) {
+		err = invoker.Group(inner)(ctx)
+		errs <- err
+		return err
+	}
+
+	err := invoker.Run(context.Background(), nested, fail)
+	require.Equal(errSample, err)
+	require.Equal(errSample, <-errs)
+}
+
+// Test that the group's own error wins over the parent.
+func TestGroupError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	inner := invoker.New(invoker.Wait, func(ctx context.Context) (err error) {
+		return errSample
+	})
+
+	middle := invoker.New(invoker.Group(inner))
+
+	err := invoker.Run(context.Background(), invoker.Group(middle))
+	require.Equal(errSample, err)
+}
+
+// Test that a task stops once an independent group has finished.
+func TestUntilDone(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	other := invoker.New(invoker.Wait)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- other.Run(ctx)
+	}()
+
+	go cancel()
+
+	err := invoker.Run(context.Background(), invoker.UntilDone(other))
+	require.NoError(err)
+	require.Equal(context.Canceled, <-errs)
+
+	select {
+	case <-other.Done():
+	default:
+		require.Fail("done not closed")
+	}
+}
+
+// Test that Done is closed immediately when the group has already finished.
+func TestUntilDoneFinished(t *testing.T) {
+	require := require.New(t)
+
+	other := invoker.New()
+	require.NoError(other.Run(context.Background()))
+
+	err := invoker.Run(context.Background(), invoker.UntilDone(other))
+	require.NoError(err)
+}
+
+// Test that UntilDone returns the context error if the group never finishes.
+func TestUntilDoneCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	other := invoker.New(invoker.Wait)
+
+	err := invoker.Run(ctx, invoker.UntilDone(other))
+	require.Equal(context.Canceled, err)
+}
@@ -0,0 +1,99 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// GRPC is implemented by *grpc.Server from google.golang.org/grpc.
+type GRPC interface {
+	Serve(lis net.Listener) error
+	GracefulStop()
+	Stop()
+}
+
+// The message of grpc.ErrServerStopped, compared to avoid depending on grpc unless WithServerStopped is used.
+const grpcStopped = "grpc: the server has been stopped"
+
+// GRPCOption configures GRPCServer.
+type GRPCOption func(g *grpcServer)
+
+type grpcServer struct {
+	stopped error
+}
+
+// WithServerStopped provides grpc.ErrServerStopped, so it's matched with errors.Is instead of by its message.
+func WithServerStopped(err error) GRPCOption {
+	return func(g *grpcServer) {
+		g.stopped = err
+	}
+}
+
+// isStopped returns true if the error means the server was stopped elsewhere.
+func (g *grpcServer) isStopped(err error) bool {
+	if g.stopped != nil {
+		return errors.Is(err, g.stopped)
+	}
+
+	// Fall back to the message, checking any wrapped errors too.
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err.Error() == grpcStopped {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GRPCServer returns a Task that serves gRPC on the listener until the context is done.
+// On cancel, the server is stopped gracefully and then forcefully if it hasn't finished after the grace period.
+// The server being stopped elsewhere, ex. grpc.ErrServerStopped, is treated as a clean exit.
+// Pass WithServerStopped(grpc.ErrServerStopped) to match it by value, otherwise it's matched by its message.
+func GRPCServer(srv GRPC, lis net.Listener, grace time.Duration, opts ...GRPCOption) (t Task) {
+	var g grpcServer
+	for _, opt := range opts {
+		opt(&g)
+	}
+
+	return func(ctx context.Context) (err error) {
+		done := make(chan error, 1)
+
+		go func() {
+			done <- srv.Serve(lis)
+		}()
+
+		select {
+		case err = <-done:
+			if err != nil && g.isStopped(err) {
+				return nil
+			}
+
+			return err
+		case <-ctx.Done():
+		}
+
+		stopped := make(chan struct{})
+
+		go func() {
+			srv.GracefulStop()
+			close(stopped)
+		}()
+
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+
+		select {
+		case <-stopped:
+		case <-timer.C:
+			// Stop also unblocks GracefulStop.
+			srv.Stop()
+			<-stopped
+		}
+
+		<-done
+
+		return ctx.Err()
+	}
+}
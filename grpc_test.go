@@ -0,0 +1,142 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// server mimics *grpc.Server, where GracefulStop waits for active requests to drain.
+type server struct {
+	once    sync.Once
+	stopped chan struct{}
+	drained chan struct{}
+
+	mutex  sync.Mutex
+	forced bool
+}
+
+func newServer() *server {
+	return &server{
+		stopped: make(chan struct{}),
+		drained: make(chan struct{}),
+	}
+}
+
+func (s *server) Serve(lis net.Listener) (err error) {
+	<-s.stopped
+	return nil
+}
+
+func (s *server) GracefulStop() {
+	s.once.Do(func() { close(s.stopped) })
+	<-s.drained
+}
+
+func (s *server) Stop() {
+	s.mutex.Lock()
+	s.forced = true
+	s.mutex.Unlock()
+
+	s.once.Do(func() { close(s.stopped) })
+	close(s.drained)
+}
+
+// Test that the server is stopped gracefully on cancel.
+func TestGRPCServer(t *testing.T) {
+	require := require.New(t)
+
+	srv := newServer()
+	close(srv.drained)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, invoker.GRPCServer(srv, nil, time.Hour))
+	require.Equal(context.Canceled, err)
+	require.False(srv.forced)
+}
+
+// Test that the server is stopped forcefully after the grace period.
+func TestGRPCServerGrace(t *testing.T) {
+	require := require.New(t)
+
+	srv := newServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, invoker.GRPCServer(srv, nil, time.Millisecond))
+	require.Equal(context.Canceled, err)
+	require.True(srv.forced)
+}
+
+// stoppedServer returns the same error as *grpc.Server when it was already stopped.
+type stoppedServer struct {
+	server
+}
+
+func (s *stoppedServer) Serve(lis net.Listener) (err error) {
+	return fmt.Errorf("grpc: the server has been stopped")
+}
+
+// Test that a server stopped elsewhere is a clean exit.
+func TestGRPCServerStopped(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.GRPCServer(&stoppedServer{}, nil, time.Hour))
+	require.NoError(err)
+}
+
+// errServerStopped stands in for grpc.ErrServerStopped, which has the same message.
+var errServerStopped = fmt.Errorf("grpc: the server has been stopped")
+
+// wrappedServer returns the stopped error wrapped with more context.
+type wrappedServer struct {
+	server
+}
+
+func (s *wrappedServer) Serve(lis net.Listener) (err error) {
+	return fmt.Errorf("serve: %w", errServerStopped)
+}
+
+// Test that the stopped error is matched by value, even when wrapped.
+func TestGRPCServerStoppedValue(t *testing.T) {
+	require := require.New(t)
+
+	stopped := invoker.WithServerStopped(errServerStopped)
+
+	err := invoker.Run(context.Background(), invoker.GRPCServer(&wrappedServer{}, nil, time.Hour, stopped))
+	require.NoError(err)
+
+	// A different error with the same message isn't matched once the value is provided.
+	err = invoker.Run(context.Background(), invoker.GRPCServer(&stoppedServer{}, nil, time.Hour, stopped))
+	require.EqualError(err, "grpc: the server has been stopped")
+
+	// The message is still matched when wrapped without the value.
+	err = invoker.Run(context.Background(), invoker.GRPCServer(&wrappedServer{}, nil, time.Hour))
+	require.NoError(err)
+}
+
+// errServer fails to serve, ex. the listener was closed.
+type errServer struct {
+	server
+}
+
+func (s *errServer) Serve(lis net.Listener) (err error) {
+	return fmt.Errorf("hello")
+}
+
+// Test that other errors from Serve are returned.
+func TestGRPCServerError(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.GRPCServer(&errServer{}, nil, time.Hour))
+	require.EqualError(err, "hello")
+}
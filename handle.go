@@ -0,0 +1,102 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTaskCancelled is the cancellation cause seen by tasks cancelled with TaskHandle.Cancel.
+var ErrTaskCancelled = fmt.Errorf("task cancelled")
+
+// errSkipped is returned by a task cancelled by its handle, so it's not reported as a result.
+var errSkipped = fmt.Errorf("skipped")
+
+// TaskHandle is returned when adding tasks, allowing them to be cancelled without affecting the rest of the group.
+// The zero value is ready to use.
+type TaskHandle struct {
+	// set before the handle is returned, see Err
+	err error
+
+	mutex     sync.Mutex
+	cancelled bool
+
+	// the cancel function of each running task, by an arbitrary ID
+	running map[int]context.CancelCauseFunc
+	next    int
+}
+
+// Cancel cancels the tasks, which see ErrTaskCancelled as the cause, or skips them if they haven't started.
+// A cancelled task that returns nil or context.Canceled leaves no result: it's not counted in the Outcome, can't win a Race or Any, and doesn't cancel a Run.
+// Any other error is still returned as usual.
+func (h *TaskHandle) Cancel() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.cancelled = true
+
+	for _, cancel := range h.running {
+		cancel(ErrTaskCancelled)
+	}
+
+	h.running = nil
+}
+
+// Err returns ErrTooManyTasks if the tasks were not added because of WithMaxRunning, otherwise nil.
+func (h *TaskHandle) Err() error {
+	return h.err
+}
+
+// track registers the cancel function of a running task, returning false if the handle was already cancelled.
+func (h *TaskHandle) track(cancel context.CancelCauseFunc) (id int, ok bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.cancelled {
+		return 0, false
+	}
+
+	if h.running == nil {
+		h.running = make(map[int]context.CancelCauseFunc)
+	}
+
+	id = h.next
+	h.next += 1
+	h.running[id] = cancel
+
+	return id, true
+}
+
+// untrack removes the cancel function once the task has returned, returning true if the handle was cancelled.
+func (h *TaskHandle) untrack(id int) (cancelled bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.running, id)
+
+	return h.cancelled
+}
+
+// wrap runs the task with a context that is also cancelled by the handle.
+func (h *TaskHandle) wrap(t Task) Task {
+	return func(ctx context.Context) (err error) {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		id, ok := h.track(cancel)
+		if !ok {
+			// Cancelled before it started.
+			return errSkipped
+		}
+
+		err = t(ctx)
+
+		cancelled := h.untrack(id)
+		if cancelled && (err == nil || errors.Is(err, context.Canceled)) {
+			return errSkipped
+		}
+
+		return err
+	}
+}
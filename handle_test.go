@@ -0,0 +1,183 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that cancelling a handle only cancels its tasks.
+func TestTaskHandle(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+
+	var cause error
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	victim := tasks.Add(func(ctx context.Context) (err error) {
+		close(started)
+		<-ctx.Done()
+		cause = context.Cause(ctx)
+		close(cancelled)
+		return ctx.Err()
+	})
+
+	count := uint64(0)
+	other := func(ctx context.Context) (err error) {
+		// Keep running until after the victim was cancelled.
+		<-cancelled
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	tasks.Add(other)
+	tasks.Add(other)
+
+	tasks.Add(func(ctx context.Context) (err error) {
+		<-started
+		victim.Cancel()
+		return nil
+	})
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(invoker.ErrTaskCancelled, cause)
+	require.Equal(uint64(2), atomic.LoadUint64(&count))
+}
+
+// Test that a task cancelled before it starts is skipped.
+func TestTaskHandleBeforeStart(t *testing.T) {
+	require := require.New(t)
+
+	ran := false
+	f := func(ctx context.Context) (err error) {
+		ran = true
+		return nil
+	}
+
+	tasks := invoker.New()
+	tasks.Add(f).Cancel()
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.False(ran)
+}
+
+// Test that an error other than the cancellation is still returned.
+func TestTaskHandleError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	tasks := invoker.New()
+
+	started := make(chan struct{})
+
+	h := tasks.Add(func(ctx context.Context) (err error) {
+		close(started)
+		<-ctx.Done()
+		return errSample
+	})
+
+	tasks.Add(func(ctx context.Context) (err error) {
+		<-started
+		h.Cancel()
+		return nil
+	})
+
+	err := tasks.Run(context.Background())
+	require.Equal(errSample, err)
+}
+
+// Test that a cancelled task can't win a Race, and isn't counted in the Outcome.
+func TestTaskHandleRace(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	tasks := invoker.New()
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	victim := tasks.Add(func(ctx context.Context) (err error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+
+	tasks.Add(func(ctx context.Context) (err error) {
+		<-started
+		victim.Cancel()
+
+		// Return after the victim so it would have won.
+		<-cancelled
+		time.Sleep(time.Millisecond)
+
+		return errSample
+	})
+
+	err := tasks.Race(context.Background())
+	require.Equal(errSample, err)
+
+	o, err := tasks.RunResult(context.Background())
+	require.Equal(invoker.ErrFinished, err)
+	require.Nil(o)
+}
+
+// Test that a cancelled task isn't counted in the Outcome.
+func TestTaskHandleOutcome(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+
+	victim := tasks.Add(invoker.Wait)
+	tasks.Add(func(ctx context.Context) (err error) {
+		victim.Cancel()
+		return nil
+	})
+
+	o, err := tasks.RunResult(context.Background())
+	require.NoError(err)
+	require.Equal(1, o.Succeeded)
+	require.Equal(0, o.Failed)
+}
+
+// Test that every way of adding tasks returns a handle.
+func TestTaskHandleVariants(t *testing.T) {
+	require := require.New(t)
+
+	ran := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&ran, 1)
+		return nil
+	}
+
+	tasks := invoker.New()
+
+	tasks.AddPriority(1, f).Cancel()
+	tasks.AddWeighted(1, f).Cancel()
+	tasks.AddNamed("f", f).Cancel()
+	tasks.AddWithContext(func(parent context.Context) (context.Context, context.CancelFunc) {
+		return context.WithCancel(parent)
+	}, f).Cancel()
+
+	h, ok := tasks.TryAdd(f)
+	require.True(ok)
+	h.Cancel()
+
+	h, err := tasks.AddWait(context.Background(), f)
+	require.NoError(err)
+	h.Cancel()
+
+	err = tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(0), atomic.LoadUint64(&ran))
+}
@@ -0,0 +1,70 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotChecked is reported by HealthLoop before the checks have run.
+var ErrNotChecked = fmt.Errorf("health not checked")
+
+// HealthLoop returns a Task that runs the health checks immediately and then every interval, until cancelled.
+// The status function returns the latest result: nil if all checks passed, or the joined errors otherwise.
+func HealthLoop(interval time.Duration, checks ...func(ctx context.Context) (err error)) (t Task, status func() error) {
+	var mutex sync.Mutex
+	latest := ErrNotChecked
+
+	status = func() error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return latest
+	}
+
+	t = func(ctx context.Context) (err error) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			var errs []error
+			for _, check := range checks {
+				err = check(ctx)
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+
+			mutex.Lock()
+			latest = errors.Join(errs...)
+			mutex.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+
+	return t, status
+}
+
+// Observe returns a Task that runs the task and stores its result in the sink, clearing it on success.
+// This allows a health handler to read the latest result of a repeating task, ex. with TickTolerant.
+// The sink is nil until the task first returns, and the stored error is never modified.
+func Observe(t Task, sink *atomic.Pointer[error]) Task {
+	return func(ctx context.Context) (err error) {
+		err = t(ctx)
+		if err == nil {
+			sink.Store(nil)
+		} else {
+			sink.Store(&err)
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,131 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the status follows a check toggling between pass and fail.
+func TestHealthLoop(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	var failing atomic.Bool
+	check := func(ctx context.Context) (err error) {
+		if failing.Load() {
+			return errSample
+		}
+
+		return nil
+	}
+
+	loop, status := invoker.HealthLoop(time.Millisecond, check)
+	require.Equal(invoker.ErrNotChecked, status())
+
+	// Wait until the status satisfies the condition, giving up after a while.
+	until := func(cond func(err error) bool) error {
+		for i := 0; i < 1000; i += 1 {
+			if cond(status()) {
+				return nil
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+
+		return fmt.Errorf("timed out with status: %v", status())
+	}
+
+	toggle := func(ctx context.Context) (err error) {
+		err = until(func(err error) bool { return err == nil })
+		if err != nil {
+			return err
+		}
+
+		failing.Store(true)
+
+		err = until(func(err error) bool { return errors.Is(err, errSample) })
+		if err != nil {
+			return err
+		}
+
+		failing.Store(false)
+
+		return until(func(err error) bool { return err == nil })
+	}
+
+	err := invoker.Race(context.Background(), loop, toggle)
+	require.NoError(err)
+}
+
+// Test that Observe records the latest result of each run.
+func TestObserve(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	failing := false
+	f := func(ctx context.Context) (err error) {
+		if failing {
+			return errSample
+		}
+
+		return nil
+	}
+
+	var sink atomic.Pointer[error]
+	task := invoker.Observe(f, &sink)
+
+	require.Nil(sink.Load())
+
+	failing = true
+	err := invoker.Run(context.Background(), task)
+	require.Equal(errSample, err)
+	require.NotNil(sink.Load())
+	require.Equal(errSample, *sink.Load())
+
+	failing = false
+	err = invoker.Run(context.Background(), task)
+	require.NoError(err)
+	require.Nil(sink.Load())
+}
+
+// Test that the sink can be read while a repeating task updates it.
+func TestObserveConcurrent(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		if count%2 == 0 {
+			return errSample
+		}
+
+		return nil
+	}
+
+	var sink atomic.Pointer[error]
+	loop := invoker.TickTolerant(time.Millisecond, invoker.Observe(f, &sink), func(err error) {})
+
+	read := func(ctx context.Context) (err error) {
+		for ctx.Err() == nil {
+			if p := sink.Load(); p != nil && *p != errSample {
+				return fmt.Errorf("unexpected error: %w", *p)
+			}
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), loop, read, invoker.Timeout(20*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
@@ -0,0 +1,44 @@
+package invoker
+
+import (
+	"context"
+	"net/http"
+)
+
+// ReadyHandler returns an http.Handler that can be used as a readiness probe for the group.
+// The group is ready when it is running and no task has returned an error.
+// Otherwise 503 Service Unavailable is returned with the error, if any.
+func ReadyHandler(ts *Tasks) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		running := ts.Running()
+		err := ts.Err()
+
+		switch {
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case !running:
+			http.Error(w, "not running", http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+// HTTPDo returns a Task that sends the request with the task's context and passes the response to handle.
+// The request is cancelled when the context is done, and the body is closed after handle returns.
+// The error from the request or handle is returned.
+func HTTPDo(client *http.Client, req *http.Request, handle func(resp *http.Response) error) (t Task) {
+	return func(ctx context.Context) (err error) {
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			// The body was either read by handle or is being discarded.
+			_ = resp.Body.Close()
+		}()
+
+		return handle(resp)
+	}
+}
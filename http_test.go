@@ -0,0 +1,94 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(h http.Handler) int {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	return w.Code
+}
+
+// Test the readiness before, during, and after a run.
+func TestReadyHandler(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+	handler := invoker.ReadyHandler(tasks)
+
+	require.Equal(http.StatusServiceUnavailable, probe(handler))
+
+	errSample := fmt.Errorf("hello")
+	codes := make(chan int, 1)
+
+	tasks.Add(func(ctx context.Context) (err error) {
+		codes <- probe(handler)
+		return errSample
+	})
+
+	err := tasks.Run(context.Background())
+	require.Equal(errSample, err)
+	require.Equal(http.StatusOK, <-codes)
+	require.Equal(http.StatusServiceUnavailable, probe(handler))
+}
+
+// Test that the response is passed to the handler.
+func TestHTTPDo(t *testing.T) {
+	require := require.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	require.NoError(err)
+
+	var body []byte
+	handle := func(resp *http.Response) (err error) {
+		body, err = io.ReadAll(resp.Body)
+		return err
+	}
+
+	err = invoker.Run(context.Background(), invoker.HTTPDo(srv.Client(), req, handle))
+	require.NoError(err)
+	require.Equal("hello", string(body))
+}
+
+// Test that the request is cancelled mid-request.
+func TestHTTPDoCancel(t *testing.T) {
+	require := require.New(t)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	require.NoError(err)
+
+	called := false
+	handle := func(resp *http.Response) (err error) {
+		called = true
+		return nil
+	}
+
+	err = invoker.Run(context.Background(), invoker.HTTPDo(srv.Client(), req, handle), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.False(called)
+}
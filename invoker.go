@@ -2,6 +2,8 @@ package invoker
 
 import (
 	"context"
+	"errors"
+	"time"
 )
 
 // Task is a function that will execute until finished or the context is done.
@@ -12,17 +14,188 @@ func Run(ctx context.Context, tasks ...Task) (err error) {
 	return New(tasks...).Run(ctx)
 }
 
+// RunCause will execute the given tasks like Run, but uses the cause when the parent context is cancelled.
+// Tasks can see the cause via context.Cause, unless the parent context was cancelled with its own cause.
+// The parent's deadline is kept, so it's still reported as context.DeadlineExceeded rather than the cause.
+func RunCause(ctx context.Context, cause error, tasks ...Task) (err error) {
+	inner := context.WithoutCancel(ctx)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		var stop context.CancelFunc
+		inner, stop = context.WithDeadline(inner, deadline)
+		defer stop()
+	}
+
+	inner, cancel := context.WithCancelCause(inner)
+	defer cancel(nil)
+
+	stop := context.AfterFunc(ctx, func() {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			// The deadline is reported by the inner context instead.
+			return
+		}
+
+		// Prefer the parent's cause if it has a more specific one.
+		parent := context.Cause(ctx)
+		if parent == ctx.Err() {
+			parent = cause
+		}
+
+		cancel(parent)
+	})
+	defer stop()
+
+	return Run(inner, tasks...)
+}
+
 // Race will execute the given tasks, returning the first result and canceling any remaining tasks.
 func Race(ctx context.Context, tasks ...Task) (err error) {
 	return New(tasks...).Race(ctx)
 }
 
+// Any will execute the given tasks, returning nil once one succeeds and canceling any remaining tasks.
+// If every task fails, the errors are joined together.
+func Any(ctx context.Context, tasks ...Task) (err error) {
+	return New(tasks...).Any(ctx)
+}
+
+// RaceSuccess will execute the given tasks, returning nil once one succeeds without canceling the remaining tasks.
+// If every task fails, the errors are joined together.
+// NOTE: The remaining tasks keep running in the background until they return or the context is done.
+func RaceSuccess(ctx context.Context, tasks ...Task) (err error) {
+	return New(tasks...).RaceSuccess(ctx)
+}
+
+// Hedge will execute the primary task, and if it hasn't returned within the delay, also executes the backup tasks.
+// The first result is returned and any remaining tasks are canceled, like Race.
+func Hedge(ctx context.Context, delay time.Duration, primary Task, backups ...Task) (err error) {
+	tasks := make([]Task, 0, 1+len(backups))
+	tasks = append(tasks, primary)
+
+	for _, backup := range backups {
+		tasks = append(tasks, At(time.Now().Add(delay), backup))
+	}
+
+	return Race(ctx, tasks...)
+}
+
+// RaceVerbose will execute the given tasks like Race, but also returns any errors from the remaining tasks that were not caused by the cancellation.
+func RaceVerbose(ctx context.Context, tasks ...Task) (extra []error, err error) {
+	return New(tasks...).RaceVerbose(ctx)
+}
+
+// RunBounded will execute the given tasks like Run, but cancels them after the timeout.
+// ErrTimeout is returned if the timeout fired, while the parent's error is returned if its deadline fired first.
+func RunBounded(ctx context.Context, timeout time.Duration, tasks ...Task) (err error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, ErrTimeout)
+	defer cancel()
+
+	err = Run(ctx, tasks...)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		// Figure out which deadline fired.
+		return context.Cause(ctx)
+	}
+
+	return err
+}
+
+// RunStagger will execute the given tasks like Run, but starts each task after the previous by the given delay.
+// No further tasks are started once the context is done.
+func RunStagger(ctx context.Context, delay time.Duration, tasks ...Task) (err error) {
+	staggered := make([]Task, len(tasks))
+
+	for i, t := range tasks {
+		t, wait := t, Sleep(time.Duration(i)*delay)
+
+		staggered[i] = func(ctx context.Context) (err error) {
+			err = wait(ctx)
+			if err != nil {
+				return err
+			}
+
+			return t(ctx)
+		}
+	}
+
+	return Run(ctx, staggered...)
+}
+
+// RunAll will execute the given tasks until they have all finished, without cancelling on error.
+// If any task failed, a MultiError is returned with a TaskError for each failure.
+func RunAll(ctx context.Context, tasks ...Task) (err error) {
+	errs := make([]error, len(tasks))
+	wrapped := make([]Task, len(tasks))
+
+	for i, t := range tasks {
+		i, t := i, t
+
+		wrapped[i] = func(ctx context.Context) (err error) {
+			errs[i] = t(ctx)
+			return nil
+		}
+	}
+
+	err = Run(ctx, wrapped...)
+	if err != nil {
+		return err
+	}
+
+	var me MultiError
+	for i, err := range errs {
+		if err != nil {
+			me.errs = append(me.errs, TaskError{Index: i, Err: err})
+		}
+	}
+
+	if len(me.errs) > 0 {
+		return me
+	}
+
+	return nil
+}
+
+// RunResilient will execute the given tasks like RunAll, but also converts any panic into an ErrPanic.
+// This allows one bad item in a batch to fail without aborting the others.
+func RunResilient(ctx context.Context, tasks ...Task) (err error) {
+	recovered := make([]Task, len(tasks))
+	for i, t := range tasks {
+		recovered[i] = Recover(t)
+	}
+
+	return RunAll(ctx, recovered...)
+}
+
+// RunSeq will execute the given tasks one at a time in order on the current goroutine, returning the first error.
+// No further tasks are started once the context is done.
+// This is useful for deterministic tests and debugging.
+func RunSeq(ctx context.Context, tasks ...Task) (err error) {
+	for _, t := range tasks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err = t(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Wait blocks until the context is canceled
 func Wait(ctx context.Context) (err error) {
 	<-ctx.Done()
 	return ctx.Err()
 }
 
+// WaitNil blocks until the context is canceled, then returns nil.
+// Unlike Wait, this won't contribute a cancellation error when the tasks are cancelled.
+func WaitNil(ctx context.Context) (err error) {
+	<-ctx.Done()
+	return nil
+}
+
 // Noop returns immediately
 func Noop(ctx context.Context) (err error) {
 	return nil
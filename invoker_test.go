@@ -2,9 +2,12 @@ package invoker_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/kixelated/invoker"
 	"github.com/stretchr/testify/require"
@@ -19,6 +22,53 @@ func TestRunEmpty(t *testing.T) {
 	require.NoError(err)
 }
 
+// Test the result of each mode with no tasks.
+func TestEmptyModes(t *testing.T) {
+	require := require.New(t)
+
+	require.NoError(invoker.New().Run(context.Background()))
+	require.NoError(invoker.New().Race(context.Background()))
+	require.NoError(invoker.New().Any(context.Background()))
+	require.NoError(invoker.New().RaceSuccess(context.Background()))
+
+	// Repeat waits for tasks to be added.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := invoker.New().Repeat(ctx)
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that AllowEmpty makes every mode return nil immediately with no tasks.
+func TestAllowEmpty(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(invoker.New().Apply(invoker.AllowEmpty()).Run(ctx))
+	require.NoError(invoker.New().Apply(invoker.AllowEmpty()).Race(ctx))
+	require.NoError(invoker.New().Apply(invoker.AllowEmpty()).Any(ctx))
+	require.NoError(invoker.New().Apply(invoker.AllowEmpty()).RaceSuccess(ctx))
+
+	tasks := invoker.New().Apply(invoker.AllowEmpty())
+
+	err := tasks.Repeat(ctx)
+	require.NoError(err)
+	require.Equal(invoker.StatusDone, tasks.Status())
+
+	// The option doesn't change a Repeat with tasks.
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		return nil
+	}
+
+	err = invoker.New(f).Apply(invoker.AllowEmpty(), invoker.WithMaxIterations(2)).Repeat(ctx)
+	require.NoError(err)
+	require.Equal(2, count)
+}
+
 // Test with all successes.
 func TestRunSuccess(t *testing.T) {
 	require := require.New(t)
@@ -174,6 +224,210 @@ func TestRunRunning(t *testing.T) {
 	require.Equal(context.Canceled, <-errs)
 }
 
+// Test that a parent's cancel cause is visible to tasks.
+func TestRunParentCause(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errSample)
+
+	causes := make(chan error, 1)
+	err := invoker.Run(ctx, func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		causes <- context.Cause(ctx)
+
+		return ctx.Err()
+	})
+
+	require.Equal(context.Canceled, err)
+	require.Equal(errSample, <-causes)
+}
+
+// Test that RunCause forwards the cause when the parent is cancelled.
+func TestRunCause(t *testing.T) {
+	require := require.New(t)
+
+	errDeploy := fmt.Errorf("deploying new version")
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	causes := make(chan error, 1)
+	f := func(ctx context.Context) (err error) {
+		cancel()
+		<-ctx.Done()
+		causes <- context.Cause(ctx)
+
+		return ctx.Err()
+	}
+
+	err := invoker.RunCause(ctx, errDeploy, f)
+	require.Equal(context.Canceled, err)
+	require.Equal(errDeploy, <-causes)
+}
+
+// Test that RunCause prefers the parent's own cause.
+func TestRunCauseParent(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+	errDeploy := fmt.Errorf("deploying new version")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errSample)
+
+	causes := make(chan error, 1)
+	err := invoker.RunCause(ctx, errDeploy, func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		causes <- context.Cause(ctx)
+
+		return ctx.Err()
+	})
+
+	require.Equal(context.Canceled, err)
+	require.Equal(errSample, <-causes)
+}
+
+// Test that RunCause still reports the parent's deadline.
+func TestRunCauseDeadline(t *testing.T) {
+	require := require.New(t)
+
+	errDeploy := fmt.Errorf("deploying new version")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	causes := make(chan error, 1)
+	err := invoker.RunCause(ctx, errDeploy, func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		causes <- context.Cause(ctx)
+
+		return ctx.Err()
+	})
+
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(context.DeadlineExceeded, <-causes)
+}
+
+// Test that concurrent callers of RunShared all receive the same result.
+func TestRunShared(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := uint64(0)
+	release := make(chan struct{})
+
+	tasks := invoker.New(func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		<-release
+
+		return errSample
+	})
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i += 1 {
+		go func() {
+			errs <- tasks.RunShared(context.Background())
+		}()
+	}
+
+	close(release)
+
+	for i := 0; i < 5; i += 1 {
+		require.Equal(errSample, <-errs)
+	}
+
+	require.Equal(uint64(1), atomic.LoadUint64(&count))
+
+	// Late callers get the same result too.
+	require.Equal(errSample, tasks.RunShared(context.Background()))
+}
+
+// Test that every caller of RunShared gets the error after it has been post-processed.
+func TestRunSharedFinal(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.NewWithTimeout(10*time.Millisecond, invoker.Wait)
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i += 1 {
+		go func() {
+			errs <- tasks.RunShared(context.Background())
+		}()
+	}
+
+	for i := 0; i < 5; i += 1 {
+		require.Equal(invoker.ErrDeadline, <-errs)
+	}
+}
+
+// Test that a waiting caller of RunShared can give up.
+func TestRunSharedCancel(t *testing.T) {
+	require := require.New(t)
+
+	started := make(chan struct{})
+	tasks := invoker.New(func(ctx context.Context) (err error) {
+		close(started)
+		return invoker.Wait(ctx)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tasks.RunShared(ctx)
+	}()
+
+	<-started
+
+	waiting, stop := context.WithCancel(context.Background())
+	stop()
+
+	require.Equal(context.Canceled, tasks.RunShared(waiting))
+
+	cancel()
+	require.Equal(context.Canceled, <-errs)
+}
+
+// Test that a task added with a short deadline times out while the group runs on.
+func TestRunAddWithContext(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	short := func(ctx context.Context) (err error) {
+		err = invoker.Wait(ctx)
+		errs <- err
+
+		return nil
+	}
+
+	derive := func(parent context.Context) (context.Context, context.CancelFunc) {
+		return context.WithTimeout(parent, time.Millisecond)
+	}
+
+	var shortErr, groupErr error
+	tasks := invoker.New(func(ctx context.Context) (err error) {
+		shortErr = <-errs
+		groupErr = ctx.Err()
+		cancel()
+
+		return nil
+	}, invoker.Wait)
+
+	tasks.AddWithContext(derive, short)
+
+	err := tasks.Run(ctx)
+	require.Equal(context.Canceled, err)
+	require.Equal(context.DeadlineExceeded, shortErr)
+	require.NoError(groupErr)
+}
+
 // Test with not tasks.
 func TestRaceEmpty(t *testing.T) {
 	require := require.New(t)
@@ -354,6 +608,180 @@ func TestRaceRunning(t *testing.T) {
 	require.Equal(context.Canceled, <-errs)
 }
 
+// Test that the first success cancels the remaining tasks, even after an error.
+func TestAnySuccess(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	failed := make(chan struct{})
+	fail := func(ctx context.Context) (err error) {
+		close(failed)
+		return errSample
+	}
+
+	succeed := func(ctx context.Context) (err error) {
+		<-failed
+		return nil
+	}
+
+	err := invoker.Any(context.Background(), fail, succeed, invoker.Wait)
+	require.NoError(err)
+}
+
+// Test that every error is returned if all of the tasks fail.
+func TestAnyError(t *testing.T) {
+	require := require.New(t)
+
+	errFirst := fmt.Errorf("first")
+	errSecond := fmt.Errorf("second")
+
+	first := func(ctx context.Context) (err error) {
+		return errFirst
+	}
+
+	second := func(ctx context.Context) (err error) {
+		return errSecond
+	}
+
+	err := invoker.Any(context.Background(), first, second)
+	require.Error(err)
+	require.True(errors.Is(err, errFirst))
+	require.True(errors.Is(err, errSecond))
+}
+
+// Test that Any returns the cancellation if the parent is cancelled before a success.
+func TestAnyCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		cancel() // cancel inside of the function to ensure invoker is started
+		<-ctx.Done()
+		atomic.AddUint64(&count, 1)
+		return ctx.Err()
+	}
+
+	err := invoker.Any(ctx, f, f, f)
+	require.True(errors.Is(err, context.Canceled))
+	require.Equal(uint64(3), atomic.LoadUint64(&count))
+}
+
+// Test that RaceSuccess returns the first success while the other tasks keep running.
+func TestRaceSuccessDetached(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	succeed := func(ctx context.Context) (err error) {
+		return nil
+	}
+
+	release := make(chan struct{})
+	slow := func(ctx context.Context) (err error) {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	tasks := invoker.New(fail, slow, succeed)
+
+	err := tasks.RaceSuccess(context.Background())
+	require.NoError(err)
+
+	// The slow task was not cancelled, so the group is still running.
+	time.Sleep(time.Millisecond)
+	require.True(tasks.Running())
+
+	close(release)
+
+	// The group finishes once the slow task returns, with the success.
+	err = tasks.Wait()
+	require.NoError(err)
+	require.False(tasks.Running())
+}
+
+// Test that RaceSuccess joins every error if all of the tasks fail.
+func TestRaceSuccessFailures(t *testing.T) {
+	require := require.New(t)
+
+	errFirst := fmt.Errorf("first")
+	errSecond := fmt.Errorf("second")
+
+	first := func(ctx context.Context) (err error) {
+		return errFirst
+	}
+
+	// A failure doesn't cancel the other tasks either.
+	second := func(ctx context.Context) (err error) {
+		time.Sleep(time.Millisecond)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return errSecond
+	}
+
+	err := invoker.RaceSuccess(context.Background(), first, second)
+	require.Error(err)
+	require.True(errors.Is(err, errFirst))
+	require.True(errors.Is(err, errSecond))
+}
+
+// Test that RaceSuccess can't be called while the group is running.
+func TestRaceSuccessRunning(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New(invoker.Wait)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tasks.Run(ctx)
+
+	for !tasks.Running() {
+		time.Sleep(time.Millisecond)
+	}
+
+	err := tasks.RaceSuccess(context.Background())
+	require.Equal(invoker.ErrRunning, err)
+}
+
+// Test that the group's default timeout returns ErrDeadline.
+func TestRunDefaultTimeout(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.NewWithTimeout(10*time.Millisecond, invoker.Wait)
+
+	err := tasks.Run(context.Background())
+	require.Equal(invoker.ErrDeadline, err)
+	require.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+// Test that an earlier deadline from the caller wins over the group's default timeout.
+func TestRunDefaultTimeoutCaller(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	tasks := invoker.NewWithTimeout(time.Hour, invoker.Wait)
+
+	err := tasks.Run(ctx)
+	require.Equal(context.DeadlineExceeded, err)
+}
+
 // Make sure that Repeat can be cancelled even with no tasks running.
 func TestRepeatCancel(t *testing.T) {
 	require := require.New(t)
@@ -367,3 +795,723 @@ func TestRepeatCancel(t *testing.T) {
 	err := tasks.Repeat(ctx)
 	require.Equal(context.Canceled, err)
 }
+
+// Test that the limit caps the number of concurrent tasks.
+func TestRunLimit(t *testing.T) {
+	require := require.New(t)
+
+	active := int64(0)
+	peak := int64(0)
+	count := uint64(0)
+
+	f := func(ctx context.Context) (err error) {
+		n := atomic.AddInt64(&active, 1)
+		defer atomic.AddInt64(&active, -1)
+
+		for {
+			old := atomic.LoadInt64(&peak)
+			if n <= old || atomic.CompareAndSwapInt64(&peak, old, n) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+		atomic.AddUint64(&count, 1)
+
+		return nil
+	}
+
+	tasks := invoker.New(f, f, f, f, f, f).Apply(invoker.WithLimit(2))
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(6), atomic.LoadUint64(&count))
+	require.Equal(int64(2), atomic.LoadInt64(&peak))
+}
+
+// Test that AddWait throttles a producer to the limit.
+func TestRunAddWait(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New().Apply(invoker.WithLimit(2))
+
+	release := make(chan struct{})
+	count := uint64(0)
+
+	f := func(ctx context.Context) (err error) {
+		<-release
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	producer := func(ctx context.Context) (err error) {
+		// The producer counts towards the limit, leaving one slot.
+		_, err = tasks.AddWait(ctx, f)
+		if err != nil {
+			return err
+		}
+
+		// This should block until the first task finishes.
+		added := make(chan error, 1)
+		go func() {
+			_, err := tasks.AddWait(ctx, f)
+			added <- err
+		}()
+
+		select {
+		case <-added:
+			return fmt.Errorf("AddWait did not block")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		close(release)
+
+		return <-added
+	}
+
+	tasks.Add(producer)
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(2), atomic.LoadUint64(&count))
+
+	_, err = tasks.AddWait(context.Background(), f)
+	require.Equal(invoker.ErrFinished, err)
+}
+
+// Test that AddWait can be cancelled while blocked.
+func TestRunAddWaitCancel(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New(invoker.Wait).Apply(invoker.WithLimit(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+
+	_, err := tasks.AddWait(ctx, invoker.Noop)
+	require.Equal(context.Canceled, err)
+}
+
+// Test that staggered tasks are started in order with spacing.
+func TestRunStagger(t *testing.T) {
+	require := require.New(t)
+
+	delay := 10 * time.Millisecond
+	starts := make([]time.Time, 3)
+
+	start := func(i int) invoker.Task {
+		return func(ctx context.Context) (err error) {
+			starts[i] = time.Now()
+			return nil
+		}
+	}
+
+	err := invoker.RunStagger(context.Background(), delay, start(0), start(1), start(2))
+	require.NoError(err)
+
+	for i := 1; i < len(starts); i += 1 {
+		require.True(starts[i].Sub(starts[i-1]) >= delay/2)
+	}
+}
+
+// Test that cancelling stops further tasks from starting.
+func TestRunStaggerCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		cancel()
+		return nil
+	}
+
+	err := invoker.RunStagger(ctx, time.Hour, f, f, f)
+	require.Equal(context.Canceled, err)
+	require.Equal(uint64(1), atomic.LoadUint64(&count))
+}
+
+// Test that RunSeq runs tasks in order and stops on the first error.
+func TestRunSeq(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	var order []int
+	f := func(i int, err error) invoker.Task {
+		return func(ctx context.Context) error {
+			order = append(order, i)
+			return err
+		}
+	}
+
+	err := invoker.RunSeq(context.Background(), f(0, nil), f(1, nil), f(2, errSample), f(3, nil))
+	require.Equal(errSample, err)
+	require.Equal([]int{0, 1, 2}, order)
+}
+
+// Test that RunSeq stops once the context is done.
+func TestRunSeqCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		cancel()
+
+		return nil
+	}
+
+	err := invoker.RunSeq(ctx, f, f, f)
+	require.Equal(context.Canceled, err)
+	require.Equal(1, count)
+}
+
+// Test the outcome of a run with successes and failures.
+func TestRunResult(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	f := func(ctx context.Context) (err error) {
+		return nil
+	}
+
+	fail := func(ctx context.Context) (err error) {
+		time.Sleep(5 * time.Millisecond)
+		return errSample
+	}
+
+	tasks := invoker.New(f, f, fail)
+
+	o, err := tasks.RunResult(context.Background())
+	require.Equal(errSample, err)
+	require.Equal(2, o.Succeeded)
+	require.Equal(1, o.Failed)
+	require.Equal(errSample, o.Cause)
+	require.True(o.Duration >= 5*time.Millisecond)
+
+	o, err = tasks.RunResult(context.Background())
+	require.Equal(invoker.ErrFinished, err)
+	require.Nil(o)
+}
+
+// Test the outcome of a run cancelled by the parent context.
+func TestRunResultCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+
+	o, err := invoker.New(invoker.Wait, invoker.Wait).RunResult(ctx)
+	require.Equal(context.Canceled, err)
+	require.Equal(0, o.Succeeded)
+	require.Equal(2, o.Failed)
+	require.Equal(context.Canceled, o.Cause)
+}
+
+// Test that simultaneous results are captured by RaceVerbose.
+func TestRaceVerbose(t *testing.T) {
+	require := require.New(t)
+
+	errFirst := fmt.Errorf("first")
+	errSecond := fmt.Errorf("second")
+
+	var ready sync.WaitGroup
+	ready.Add(2)
+
+	// Both tasks ignore cancellation and return at the same time.
+	f := func(err error) invoker.Task {
+		return func(ctx context.Context) error {
+			ready.Done()
+			ready.Wait()
+			return err
+		}
+	}
+
+	extra, err := invoker.RaceVerbose(context.Background(), f(errFirst), f(errSecond), invoker.Wait)
+	require.Len(extra, 1)
+	require.ElementsMatch([]error{errFirst, errSecond}, []error{err, extra[0]})
+}
+
+// Test that a fast ignored error loses to a slower success.
+func TestRaceIgnore(t *testing.T) {
+	require := require.New(t)
+
+	errNotFound := fmt.Errorf("not found")
+
+	fast := func(ctx context.Context) (err error) {
+		return errNotFound
+	}
+
+	slow := func(ctx context.Context) (err error) {
+		return invoker.Timer(10 * time.Millisecond)(ctx)
+	}
+
+	ignore := func(err error) bool {
+		return errors.Is(err, errNotFound)
+	}
+
+	tasks := invoker.New(fast, slow).Apply(invoker.RaceIgnore(ignore))
+
+	extra, err := tasks.RaceVerbose(context.Background())
+	require.NoError(err)
+	require.Equal([]error{errNotFound}, extra)
+}
+
+// Test that the ignored error is returned if every result is ignored.
+func TestRaceIgnoreAll(t *testing.T) {
+	require := require.New(t)
+
+	errNotFound := fmt.Errorf("not found")
+
+	f := func(ctx context.Context) (err error) {
+		return errNotFound
+	}
+
+	ignore := func(err error) bool {
+		return errors.Is(err, errNotFound)
+	}
+
+	tasks := invoker.New(f, f, f).Apply(invoker.RaceIgnore(ignore))
+
+	extra, err := tasks.RaceVerbose(context.Background())
+	require.Equal(errNotFound, err)
+	require.Len(extra, 2)
+}
+
+// Test that the loser callback fires once for each task that didn't win.
+func TestRaceLoser(t *testing.T) {
+	require := require.New(t)
+
+	losers := make(map[int]int)
+	loser := func(index int, err error) {
+		losers[index] += 1
+	}
+
+	winner := func(ctx context.Context) (err error) {
+		return nil
+	}
+
+	tasks := invoker.New(invoker.Wait, winner, invoker.Wait, invoker.Wait).Apply(invoker.WithRaceLoser(loser))
+
+	err := tasks.Race(context.Background())
+	require.NoError(err)
+	require.Equal(map[int]int{0: 1, 2: 1, 3: 1}, losers)
+}
+
+// Test that ignored results are reported as losers, except the one that won by default.
+func TestRaceLoserIgnore(t *testing.T) {
+	require := require.New(t)
+
+	errNotFound := fmt.Errorf("not found")
+
+	f := func(ctx context.Context) (err error) {
+		return errNotFound
+	}
+
+	ignore := func(err error) bool {
+		return errors.Is(err, errNotFound)
+	}
+
+	losers := 0
+	loser := func(index int, err error) {
+		losers += 1
+	}
+
+	tasks := invoker.New(f, f, f).Apply(invoker.RaceIgnore(ignore), invoker.WithRaceLoser(loser))
+
+	err := tasks.Race(context.Background())
+	require.Equal(errNotFound, err)
+	require.Equal(2, losers)
+}
+
+// Test that the loser callback can add tasks without deadlocking.
+func TestRaceLoserAdd(t *testing.T) {
+	require := require.New(t)
+
+	var tasks *invoker.Tasks
+
+	cleaned := make(chan struct{})
+	cleanup := func(ctx context.Context) (err error) {
+		close(cleaned)
+		return nil
+	}
+
+	loser := func(index int, err error) {
+		// The cleanup task loses too, so only add it once.
+		if index == 0 {
+			tasks.Add(cleanup)
+		}
+	}
+
+	winner := func(ctx context.Context) (err error) {
+		return nil
+	}
+
+	tasks = invoker.New(invoker.Wait, winner).Apply(invoker.WithRaceLoser(loser))
+
+	err := tasks.Race(context.Background())
+	require.NoError(err)
+
+	<-cleaned
+}
+
+// Test that Any reports the failures before the success as losers.
+func TestAnyLoser(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	losers := make(map[int]error)
+	loser := func(index int, err error) {
+		losers[index] = err
+	}
+
+	failed := make(chan struct{}, 2)
+	fail := func(ctx context.Context) (err error) {
+		failed <- struct{}{}
+		return errSample
+	}
+
+	// Succeed after the failures.
+	success := func(ctx context.Context) (err error) {
+		<-failed
+		<-failed
+
+		return nil
+	}
+
+	tasks := invoker.New(fail, success, fail, invoker.Wait).Apply(invoker.WithRaceLoser(loser))
+
+	err := tasks.Any(context.Background())
+	require.NoError(err)
+	require.Equal(map[int]error{0: errSample, 2: errSample, 3: context.Canceled}, losers)
+}
+
+// Test that the backup isn't started if the primary is fast.
+func TestHedgePrimary(t *testing.T) {
+	require := require.New(t)
+
+	started := uint64(0)
+	backup := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&started, 1)
+		return nil
+	}
+
+	errSample := fmt.Errorf("hello")
+	primary := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	err := invoker.Hedge(context.Background(), time.Hour, primary, backup)
+	require.Equal(errSample, err)
+	require.Equal(uint64(0), atomic.LoadUint64(&started))
+}
+
+// Test that the backup wins if the primary is slow.
+func TestHedgeBackup(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+	backup := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	err := invoker.Hedge(context.Background(), time.Millisecond, invoker.Wait, backup)
+	require.Equal(errSample, err)
+}
+
+// Test that cancelling the bound context cancels the tasks.
+func TestNewWithContext(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	base, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	tasks := invoker.NewWithContext(base, invoker.Wait)
+	go cancel(errSample)
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.Canceled, err)
+
+	o, err := invoker.NewWithContext(base, invoker.Wait).RunResult(context.Background())
+	require.Equal(context.Canceled, err)
+	require.Equal(errSample, o.Cause)
+}
+
+// Test that the context provided to Run is still used.
+func TestNewWithContextRun(t *testing.T) {
+	require := require.New(t)
+
+	type key struct{}
+
+	tasks := invoker.NewWithContext(context.Background(), func(ctx context.Context) (err error) {
+		if ctx.Value(key{}) != "hello" {
+			return fmt.Errorf("missing value")
+		}
+
+		return invoker.Wait(ctx)
+	})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "hello"))
+	go cancel()
+
+	err := tasks.Run(ctx)
+	require.Equal(context.Canceled, err)
+}
+
+// Test that the total weight of running tasks never exceeds the limit.
+func TestRunWeighted(t *testing.T) {
+	require := require.New(t)
+
+	inflight := int64(0)
+	peak := int64(0)
+
+	f := func(weight int64) invoker.Task {
+		return func(ctx context.Context) (err error) {
+			n := atomic.AddInt64(&inflight, weight)
+			defer atomic.AddInt64(&inflight, -weight)
+
+			if n > atomic.LoadInt64(&peak) {
+				atomic.StoreInt64(&peak, n)
+			}
+
+			time.Sleep(time.Millisecond)
+			return nil
+		}
+	}
+
+	tasks := invoker.New().Apply(invoker.WithLimit(4))
+	tasks.AddWeighted(3, f(3))
+	tasks.AddWeighted(2, f(2))
+	tasks.AddWeighted(1, f(1))
+	tasks.AddWeighted(4, f(4))
+	tasks.AddWeighted(1, f(1))
+
+	// The weight is clamped to the limit, so this won't block forever.
+	tasks.AddWeighted(10, f(4))
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.True(atomic.LoadInt64(&peak) <= 4, atomic.LoadInt64(&peak))
+}
+
+// Test that the internal timeout is identified.
+func TestRunBoundedTimeout(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	err := invoker.RunBounded(ctx, time.Millisecond, invoker.Wait)
+	require.Equal(invoker.ErrTimeout, err)
+	require.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+// Test that the parent deadline is identified.
+func TestRunBoundedParent(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	err := invoker.RunBounded(ctx, time.Hour, invoker.Wait)
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that internal cancellation is treated as success.
+func TestRunCancelIsSuccess(t *testing.T) {
+	require := require.New(t)
+
+	// A task that stops because some other context was cancelled.
+	other, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tasks := invoker.New(invoker.Context(other), invoker.Wait)
+	tasks.Apply(invoker.WithCancelIsSuccess(false))
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+}
+
+// Test that external cancellation is only treated as success when configured.
+func TestRunCancelIsSuccessExternal(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tasks := invoker.New(invoker.Wait).Apply(invoker.WithCancelIsSuccess(false))
+	err := tasks.Run(ctx)
+	require.Equal(context.Canceled, err)
+
+	tasks = invoker.New(invoker.Wait).Apply(invoker.WithCancelIsSuccess(true))
+	err = tasks.Run(ctx)
+	require.NoError(err)
+}
+
+// Test that the duration of each task is recorded.
+func TestRunTiming(t *testing.T) {
+	require := require.New(t)
+
+	var mutex sync.Mutex
+	durations := make(map[int]time.Duration)
+
+	timing := func(index int, d time.Duration, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		durations[index] = d
+	}
+
+	tasks := invoker.New(invoker.Noop, invoker.Sleep(20*time.Millisecond)).Apply(invoker.WithTiming(timing))
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Len(durations, 2)
+	require.True(durations[0] < 20*time.Millisecond, durations[0])
+	require.True(durations[1] >= 20*time.Millisecond, durations[1])
+}
+
+// Test that TryAdd doesn't execute tasks once cancelled.
+func TestRunTryAdd(t *testing.T) {
+	require := require.New(t)
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	tasks := invoker.New()
+
+	_, ok := tasks.TryAdd(f)
+	require.True(ok)
+
+	added := uint64(0)
+	tasks.Add(func(ctx context.Context) (err error) {
+		<-ctx.Done()
+
+		for i := 0; i < 100; i += 1 {
+			if _, ok := tasks.TryAdd(f); ok {
+				atomic.AddUint64(&added, 1)
+			}
+		}
+
+		return ctx.Err()
+	})
+
+	tasks.Add(invoker.Timeout(time.Millisecond))
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(uint64(0), atomic.LoadUint64(&added))
+	require.Equal(uint64(1), atomic.LoadUint64(&count))
+
+	_, ok = tasks.TryAdd(f)
+	require.False(ok)
+}
+
+// Test that a finish hook can add more tasks.
+func TestRunOnFinishAdd(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	onFinish := func(index int, err error) {
+		// Each of the first few tasks schedules the next one.
+		if index < 3 {
+			tasks.Add(f)
+		}
+	}
+
+	tasks.Add(f)
+	tasks.Apply(invoker.WithOnFinish(onFinish))
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(4), atomic.LoadUint64(&count))
+}
+
+// Test that an observer can select on Done and Wait for the final error.
+func TestRunWait(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	release := make(chan struct{})
+	tasks := invoker.New(func(ctx context.Context) (err error) {
+		<-release
+		return errSample
+	})
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tasks.Run(context.Background())
+	}()
+
+	select {
+	case <-tasks.Done():
+		require.Fail("done before finished")
+	default:
+	}
+
+	close(release)
+
+	<-tasks.Done()
+	require.Equal(errSample, tasks.Wait())
+	require.Equal(errSample, <-errs)
+}
+
+// Test that Wait and Err return the same error as Run, after it has been post-processed.
+func TestWaitFinal(t *testing.T) {
+	require := require.New(t)
+
+	// The deadline is reported as ErrDeadline.
+	tasks := invoker.NewWithTimeout(time.Millisecond, invoker.Wait)
+
+	err := tasks.Run(context.Background())
+	require.Equal(invoker.ErrDeadline, err)
+	require.Equal(err, tasks.Wait())
+	require.Equal(err, tasks.Err())
+
+	// The cancellation is reported as a success.
+	cancel := func(ctx context.Context) (err error) {
+		return context.Canceled
+	}
+
+	tasks = invoker.New(invoker.Wait, cancel).Apply(invoker.WithCancelIsSuccess(false))
+
+	err = tasks.Run(context.Background())
+	require.NoError(err)
+	require.NoError(tasks.Wait())
+
+	// The stuck tasks are reported as ErrStuck.
+	release := make(chan struct{})
+	defer close(release)
+
+	stuck := func(ctx context.Context) (err error) {
+		<-release
+		return nil
+	}
+
+	tasks = invoker.New(stuck, invoker.Timeout(time.Millisecond)).Apply(invoker.WithCancelTimeout(time.Millisecond))
+
+	err = tasks.Run(context.Background())
+	require.IsType(invoker.ErrStuck{}, err)
+	require.Equal(err, tasks.Wait())
+}
@@ -0,0 +1,62 @@
+// Package invokertest provides helpers for running invoker tasks in tests.
+// It's separate from invoker so the main package doesn't import testing.
+package invokertest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kixelated/invoker"
+)
+
+// Task returns a Task that runs the task in a test, reporting any error with tb.Errorf.
+// The task is cancelled when the test finishes, and the cleanup waits for it to return so nothing is logged afterwards.
+// Errors caused by the context being done are not reported, as that's the expected way for a background task to stop.
+//
+// NOTE: tb.Fatal must only be called from the test goroutine, so failures are never fatal here.
+// Use the result of Run/Race/Repeat in the test goroutine to stop the test early.
+func Task(tb testing.TB, t invoker.Task) invoker.Task {
+	tb.Helper()
+
+	teardown, cancel := context.WithCancel(context.Background())
+
+	var mutex sync.Mutex
+	var running sync.WaitGroup
+	closed := false
+
+	tb.Cleanup(func() {
+		mutex.Lock()
+		closed = true
+		mutex.Unlock()
+
+		cancel()
+		running.Wait()
+	})
+
+	return func(ctx context.Context) (err error) {
+		mutex.Lock()
+		if closed {
+			mutex.Unlock()
+			return teardown.Err()
+		}
+
+		running.Add(1)
+		mutex.Unlock()
+
+		defer running.Done()
+
+		ctx, stop := context.WithCancel(ctx)
+		defer stop()
+
+		defer context.AfterFunc(teardown, stop)()
+
+		err = t(ctx)
+		if err != nil && !(ctx.Err() != nil && errors.Is(err, ctx.Err())) {
+			tb.Errorf("task failed: %v", err)
+		}
+
+		return err
+	}
+}
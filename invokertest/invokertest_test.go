@@ -0,0 +1,103 @@
+package invokertest_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/kixelated/invoker/invokertest"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTB records the errors and cleanups instead of failing the test.
+type fakeTB struct {
+	testing.TB
+
+	mutex    sync.Mutex
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+// finish runs the cleanups in reverse order, like the end of a test.
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i -= 1 {
+		f.cleanups[i]()
+	}
+}
+
+// Test that Task reports a task failure to the test.
+func TestTask(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	tb := new(fakeTB)
+	defer tb.finish()
+
+	task := invokertest.Task(tb, func(ctx context.Context) (err error) {
+		return errSample
+	})
+
+	err := invoker.Run(context.Background(), task)
+	require.Equal(errSample, err)
+	require.Equal([]string{"task failed: hello"}, tb.errors)
+}
+
+// Test that Task cancels a background task when the test finishes, without reporting it.
+func TestTaskCleanup(t *testing.T) {
+	require := require.New(t)
+
+	tb := new(fakeTB)
+
+	returned := false
+	task := invokertest.Task(tb, func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		time.Sleep(time.Millisecond)
+		returned = true
+
+		return ctx.Err()
+	})
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- invoker.Run(context.Background(), task)
+	}()
+
+	time.Sleep(time.Millisecond)
+
+	// The cleanup blocks until the task has returned.
+	tb.finish()
+	require.True(returned)
+
+	err := <-errs
+	require.Equal(context.Canceled, err)
+	require.Empty(tb.errors)
+
+	// The task doesn't start once the test has finished.
+	err = invoker.Run(context.Background(), task)
+	require.Equal(context.Canceled, err)
+}
+
+// Test that Task works with a real test.
+func TestTaskReal(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invokertest.Task(t, invoker.Wait), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
@@ -0,0 +1,92 @@
+package invoker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Drain returns a Task that reads and discards from the reader until EOF, returning nil.
+// On cancel, the reader is closed if it implements io.Closer to unblock the read.
+// NOTE: Otherwise a goroutine will continue reading in the background until the read returns.
+func Drain(r io.Reader) (t Task) {
+	return func(ctx context.Context) (err error) {
+		done := make(chan error, 1)
+
+		go func() {
+			_, err := io.Copy(io.Discard, r)
+			done <- err
+		}()
+
+		select {
+		case err = <-done:
+			return err
+		case <-ctx.Done():
+		}
+
+		if c, ok := r.(io.Closer); ok {
+			// Wait for the read to return so the goroutine doesn't leak.
+			_ = c.Close()
+			<-done
+		}
+
+		return ctx.Err()
+	}
+}
+
+// ScanLines returns a Task that reads lines from the reader and calls handle for each, without the line ending.
+// It returns the first handler error, the read error, or nil at EOF.
+// The context is checked between lines, returning the context error once it's done.
+// NOTE: A blocked read won't notice the cancel until the next line arrives, unless the reader is closed.
+func ScanLines(r io.Reader, handle func(ctx context.Context, line string) (err error)) (t Task) {
+	return func(ctx context.Context) (err error) {
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			err = handle(ctx, scanner.Text())
+			if err != nil {
+				return err
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return scanner.Err()
+	}
+}
+
+// Heartbeat returns a Task that writes the message to the writer every interval, prefixed with the time in RFC 3339.
+// It loops until the context is done, returning any write error.
+func Heartbeat(interval time.Duration, w io.Writer, msg string) (t Task) {
+	return HeartbeatFunc(interval, w, func() string {
+		return msg
+	})
+}
+
+// HeartbeatFunc returns a Task like Heartbeat, but calls msg before each write for dynamic content.
+func HeartbeatFunc(interval time.Duration, w io.Writer, msg func() string) (t Task) {
+	return func(ctx context.Context) (err error) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case now := <-ticker.C:
+				_, err = fmt.Fprintf(w, "%s %s\n", now.Format(time.RFC3339), msg())
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that draining returns nil on EOF.
+func TestDrain(t *testing.T) {
+	require := require.New(t)
+
+	r, w := io.Pipe()
+
+	write := func(ctx context.Context) (err error) {
+		_, err = w.Write([]byte("hello world"))
+		if err != nil {
+			return err
+		}
+
+		return w.Close()
+	}
+
+	err := invoker.Run(context.Background(), invoker.Drain(r), write)
+	require.NoError(err)
+}
+
+// Test that a read error is returned.
+func TestDrainError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	r, w := io.Pipe()
+	require.NoError(w.CloseWithError(errSample))
+
+	err := invoker.Run(context.Background(), invoker.Drain(r))
+	require.Equal(errSample, err)
+}
+
+// Test that cancel closes the reader.
+func TestDrainCancel(t *testing.T) {
+	require := require.New(t)
+
+	r, w := io.Pipe()
+
+	err := invoker.Run(context.Background(), invoker.Drain(r), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+
+	_, err = w.Write([]byte("hello"))
+	require.Equal(io.ErrClosedPipe, err)
+}
+
+// Test that each line is handled in order, returning nil at EOF.
+func TestScanLines(t *testing.T) {
+	require := require.New(t)
+
+	var lines []string
+	handle := func(ctx context.Context, line string) (err error) {
+		lines = append(lines, line)
+		return nil
+	}
+
+	r := strings.NewReader("one\ntwo\r\nthree")
+
+	err := invoker.Run(context.Background(), invoker.ScanLines(r, handle))
+	require.NoError(err)
+	require.Equal([]string{"one", "two", "three"}, lines)
+}
+
+// Test that a handler error stops reading.
+func TestScanLinesHandlerError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	handle := func(ctx context.Context, line string) (err error) {
+		count += 1
+		if line == "two" {
+			return errSample
+		}
+
+		return nil
+	}
+
+	r := strings.NewReader("one\ntwo\nthree\n")
+
+	err := invoker.Run(context.Background(), invoker.ScanLines(r, handle))
+	require.Equal(errSample, err)
+	require.Equal(2, count)
+}
+
+// Test that a read error is returned.
+func TestScanLinesReadError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	handle := func(ctx context.Context, line string) (err error) {
+		count += 1
+		return nil
+	}
+
+	r := io.MultiReader(strings.NewReader("one\n"), iotest.ErrReader(errSample))
+
+	err := invoker.Run(context.Background(), invoker.ScanLines(r, handle))
+	require.Equal(errSample, err)
+	require.Equal(1, count)
+}
+
+// Test that the context is checked between lines.
+func TestScanLinesCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	handle := func(ctx context.Context, line string) (err error) {
+		count += 1
+		cancel()
+		return nil
+	}
+
+	r := strings.NewReader("one\ntwo\nthree\n")
+
+	err := invoker.Run(ctx, invoker.ScanLines(r, handle))
+	require.Equal(context.Canceled, err)
+	require.Equal(1, count)
+}
+
+// limitWriter writes to the buffer until the limit, then returns the error.
+type limitWriter struct {
+	buf   strings.Builder
+	limit int
+	err   error
+}
+
+func (w *limitWriter) Write(p []byte) (n int, err error) {
+	if w.limit == 0 {
+		return 0, w.err
+	}
+
+	w.limit -= 1
+	return w.buf.Write(p)
+}
+
+// Test that a heartbeat is written every interval until a write fails.
+func TestHeartbeat(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+	w := &limitWriter{limit: 3, err: errSample}
+
+	err := invoker.Run(context.Background(), invoker.Heartbeat(time.Millisecond, w, "alive"))
+	require.Equal(errSample, err)
+
+	lines := strings.Split(strings.TrimSuffix(w.buf.String(), "\n"), "\n")
+	require.Equal(3, len(lines))
+
+	for _, line := range lines {
+		stamp, msg, ok := strings.Cut(line, " ")
+		require.True(ok)
+		require.Equal("alive", msg)
+
+		_, err = time.Parse(time.RFC3339, stamp)
+		require.NoError(err)
+	}
+}
+
+// Test that HeartbeatFunc calls the function for each write.
+func TestHeartbeatFunc(t *testing.T) {
+	require := require.New(t)
+
+	w := &limitWriter{limit: 2, err: io.ErrShortWrite}
+
+	count := 0
+	msg := func() string {
+		count += 1
+		return fmt.Sprintf("beat %d", count)
+	}
+
+	err := invoker.Run(context.Background(), invoker.HeartbeatFunc(time.Millisecond, w, msg))
+	require.Equal(io.ErrShortWrite, err)
+	require.Contains(w.buf.String(), " beat 1\n")
+	require.Contains(w.buf.String(), " beat 2\n")
+}
+
+// Test that the heartbeat stops when cancelled.
+func TestHeartbeatCancel(t *testing.T) {
+	require := require.New(t)
+
+	var w strings.Builder
+
+	err := invoker.Run(context.Background(), invoker.Heartbeat(time.Hour, &w, "alive"), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal("", w.String())
+}
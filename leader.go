@@ -0,0 +1,69 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrLeaderLost is returned by Leader when the lock was lost while running the work.
+var ErrLeaderLost = fmt.Errorf("leader lock lost")
+
+// Lock is a distributed lock, ex. a lease in etcd or Consul.
+// Acquire blocks until the lock is held or the context is done.
+//
+// If the lock also has a `Lost() <-chan struct{}` method, the channel is closed when the lock is lost after being acquired.
+type Lock interface {
+	Acquire(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
+// lostLock is optionally implemented by a Lock to signal that it was lost.
+type lostLock interface {
+	Lost() <-chan struct{}
+}
+
+// Leader returns a Task that acquires the lock, runs the work while holding it, and then releases it.
+// The work is cancelled with ErrLeaderLost as the cause if the lock is lost, which is then returned.
+// The lock is released even if the context is done, and any release error is returned if the work succeeded.
+func Leader(lock Lock, work Task) (t Task) {
+	return func(ctx context.Context) (err error) {
+		err = lock.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			// Release even if the context is done.
+			release := lock.Release(context.WithoutCancel(ctx))
+			if err == nil {
+				err = release
+			}
+		}()
+
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		if ll, ok := lock.(lostLock); ok {
+			lost := ll.Lost()
+
+			stop := make(chan struct{})
+			defer close(stop)
+
+			go func() {
+				select {
+				case <-lost:
+					cancel(ErrLeaderLost)
+				case <-stop:
+				}
+			}()
+		}
+
+		err = work(ctx)
+		if err != nil && errors.Is(context.Cause(ctx), ErrLeaderLost) {
+			return ErrLeaderLost
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,116 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLock grants the lock immediately and can revoke it.
+type fakeLock struct {
+	mutex    sync.Mutex
+	acquired int
+	released int
+	err      error
+
+	lost chan struct{}
+}
+
+func newFakeLock() *fakeLock {
+	return &fakeLock{lost: make(chan struct{})}
+}
+
+func (l *fakeLock) Acquire(ctx context.Context) (err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.err != nil {
+		return l.err
+	}
+
+	l.acquired += 1
+	return nil
+}
+
+func (l *fakeLock) Release(ctx context.Context) (err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.released += 1
+	return ctx.Err()
+}
+
+func (l *fakeLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Test that the work runs while holding the lock, which is then released.
+func TestLeader(t *testing.T) {
+	require := require.New(t)
+
+	lock := newFakeLock()
+
+	err := invoker.Run(context.Background(), invoker.Leader(lock, invoker.Noop))
+	require.NoError(err)
+	require.Equal(1, lock.acquired)
+	require.Equal(1, lock.released)
+}
+
+// Test that the work is cancelled when the lock is revoked.
+func TestLeaderLost(t *testing.T) {
+	require := require.New(t)
+
+	lock := newFakeLock()
+
+	work := func(ctx context.Context) (err error) {
+		close(lock.lost)
+		return invoker.Wait(ctx)
+	}
+
+	err := invoker.Run(context.Background(), invoker.Leader(lock, work))
+	require.Equal(invoker.ErrLeaderLost, err)
+	require.Equal(1, lock.released)
+}
+
+// Test that the lock is released with a live context when the group is cancelled.
+func TestLeaderCancel(t *testing.T) {
+	require := require.New(t)
+
+	lock := newFakeLock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	work := func(ctx context.Context) (err error) {
+		cancel()
+		return invoker.Wait(ctx)
+	}
+
+	err := invoker.Run(ctx, invoker.Leader(lock, work))
+	require.Equal(context.Canceled, err)
+	require.Equal(1, lock.released)
+}
+
+// Test that the work doesn't run if the lock can't be acquired.
+func TestLeaderAcquireError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	lock := newFakeLock()
+	lock.err = errSample
+
+	ran := false
+	work := func(ctx context.Context) (err error) {
+		ran = true
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Leader(lock, work))
+	require.Equal(errSample, err)
+	require.False(ran)
+	require.Equal(0, lock.released)
+}
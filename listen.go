@@ -0,0 +1,25 @@
+package invoker
+
+import "context"
+
+// Listen returns a Task that calls the handler for each value received on the channel.
+// It returns the first handler error, or nil once the channel is closed.
+func Listen[T any](ch <-chan T, handler func(ctx context.Context, v T) (err error)) (t Task) {
+	return func(ctx context.Context) (err error) {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case v, ok := <-ch:
+				if !ok {
+					return nil
+				}
+
+				err = handler(ctx, v)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that every value is handled before the channel closes.
+func TestListen(t *testing.T) {
+	require := require.New(t)
+
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+
+	sum := 0
+	handler := func(ctx context.Context, v int) (err error) {
+		sum += v
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Listen(ch, handler))
+	require.NoError(err)
+	require.Equal(6, sum)
+}
+
+// Test that a handler error stops listening.
+func TestListenError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+
+	count := 0
+	handler := func(ctx context.Context, v int) (err error) {
+		count += 1
+		if v == 2 {
+			return errSample
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Listen(ch, handler))
+	require.Equal(errSample, err)
+	require.Equal(2, count)
+}
+
+// Test that listening stops when the context is cancelled.
+func TestListenCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan int)
+	handler := func(ctx context.Context, v int) (err error) {
+		return nil
+	}
+
+	go cancel()
+
+	err := invoker.Run(ctx, invoker.Listen(ch, handler))
+	require.Equal(context.Canceled, err)
+}
@@ -0,0 +1,65 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Main runs the tasks until an interrupt signal, returning an exit code for os.Exit.
+// On signal, the tasks are cancelled and given the grace period to return.
+// See ExitCode for how the result is converted.
+func Main(ctx context.Context, grace time.Duration, tasks ...Task) (code int) {
+	ts := New(tasks...).Apply(WithCancelTimeout(grace))
+	ts.Add(Interrupt)
+
+	err := ts.Run(ctx)
+	return ExitCode(err)
+}
+
+// Serve runs the servers until an interrupt signal, logging the startup, the signal, and the shutdown.
+// On signal, the servers are cancelled and given the grace period to return, otherwise ErrStuck is returned.
+// A signal is treated as a clean exit and returns nil, while any other error is logged and returned.
+// The default logger is used if logger is nil.
+func Serve(ctx context.Context, logger *slog.Logger, grace time.Duration, servers ...Task) (err error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	interrupt := func(ctx context.Context) (err error) {
+		err = Interrupt(ctx)
+
+		var es ErrSignal
+		if errors.As(err, &es) {
+			logger.Info("received signal, shutting down", "signal", es.Signal().String(), "grace", grace)
+		}
+
+		return err
+	}
+
+	ts := New(servers...).Apply(WithCancelTimeout(grace))
+	ts.Add(interrupt)
+
+	logger.Info("starting", "servers", len(servers))
+
+	err = ts.Run(ctx)
+	if ExitCode(err) == 0 {
+		logger.Info("stopped")
+		return nil
+	}
+
+	logger.Error("stopped", "error", err)
+	return err
+}
+
+// ExitCode converts the result of a Run into an exit code for os.Exit.
+// A nil error or ErrSignal is a clean exit and returns 0, otherwise 1.
+func ExitCode(err error) (code int) {
+	var es ErrSignal
+	if err == nil || errors.As(err, &es) {
+		return 0
+	}
+
+	return 1
+}
@@ -0,0 +1,120 @@
+package invoker_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// signalUntil sends the signal repeatedly until the channel is closed.
+// The task may not be listening yet, so a single signal could be missed.
+func signalUntil(sig syscall.Signal, done <-chan struct{}) {
+	ignoreSignal(sig)
+
+	for {
+		_ = syscall.Kill(os.Getpid(), sig)
+
+		select {
+		case <-done:
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// Test that a signal results in a clean exit.
+func TestMainSignal(t *testing.T) {
+	require := require.New(t)
+
+	done := make(chan struct{})
+	go signalUntil(syscall.SIGHUP, done)
+
+	code := invoker.Main(context.Background(), time.Second, invoker.Wait)
+	close(done)
+
+	require.Equal(0, code)
+}
+
+// Test that tasks exceeding the grace period result in an error exit.
+func TestMainGrace(t *testing.T) {
+	require := require.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	stuck := func(ctx context.Context) (err error) {
+		<-release
+		return nil
+	}
+
+	done := make(chan struct{})
+	go signalUntil(syscall.SIGHUP, done)
+
+	code := invoker.Main(context.Background(), 10*time.Millisecond, stuck)
+	close(done)
+
+	require.Equal(1, code)
+}
+
+// Test the conversion of errors into exit codes.
+func TestExitCode(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal(0, invoker.ExitCode(nil))
+	require.Equal(1, invoker.ExitCode(fmt.Errorf("hello")))
+}
+
+// Test that Serve shuts down the servers on signal and logs each step.
+func TestServe(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	stopped := false
+	server := func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		stopped = true
+		return ctx.Err()
+	}
+
+	done := make(chan struct{})
+	go signalUntil(syscall.SIGHUP, done)
+
+	err := invoker.Serve(context.Background(), logger, time.Second, server)
+	close(done)
+
+	require.NoError(err)
+	require.True(stopped)
+
+	logs := buf.String()
+	require.Contains(logs, `msg=starting servers=1`)
+	require.Contains(logs, `msg="received signal, shutting down" signal=hangup grace=1s`)
+	require.Contains(logs, `level=INFO msg=stopped`)
+}
+
+// Test that Serve logs and returns a server error.
+func TestServeError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	server := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	err := invoker.Serve(context.Background(), logger, time.Second, server)
+	require.Equal(errSample, err)
+	require.Contains(buf.String(), `level=ERROR msg=stopped error=hello`)
+}
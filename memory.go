@@ -0,0 +1,44 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// ErrMemoryExceeded is returned by MemoryGuard when the heap usage exceeds the limit.
+var ErrMemoryExceeded = fmt.Errorf("memory limit exceeded")
+
+// MemoryGuard returns a Task that returns ErrMemoryExceeded once the heap usage exceeds maxBytes, cancelling a Run group.
+// The heap is sampled immediately and then every poll interval, until the context is done.
+// This allows a service to shut down gracefully and restart before it's killed for running out of memory.
+// NOTE: Reading the memory stats briefly stops the world, so the poll interval shouldn't be too small.
+func MemoryGuard(maxBytes uint64, poll time.Duration) (t Task) {
+	return func(ctx context.Context) (err error) {
+		// Reused for every sample to avoid allocating.
+		var stats runtime.MemStats
+
+		sample := func() uint64 {
+			runtime.ReadMemStats(&stats)
+			return stats.HeapAlloc
+		}
+
+		return MemoryGuardWith(sample, maxBytes, poll)(ctx)
+	}
+}
+
+// MemoryGuardWith returns a Task like MemoryGuard, but with a custom sample of the memory usage in bytes.
+// This allows guarding a different measure, ex. the resident set size, or a fake sample for testing.
+func MemoryGuardWith(sample func() uint64, maxBytes uint64, poll time.Duration) (t Task) {
+	return func(ctx context.Context) (err error) {
+		err = Until(poll, func(ctx context.Context) (ok bool, err error) {
+			return sample() > maxBytes, nil
+		})(ctx)
+		if err != nil {
+			return err
+		}
+
+		return ErrMemoryExceeded
+	}
+}
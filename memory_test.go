@@ -0,0 +1,47 @@
+package invoker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the group is cancelled once the sample crosses the threshold.
+func TestMemoryGuard(t *testing.T) {
+	require := require.New(t)
+
+	// Grows by 100 bytes with each sample.
+	usage := uint64(0)
+	sample := func() uint64 {
+		return atomic.AddUint64(&usage, 100)
+	}
+
+	cancelled := make(chan error, 1)
+	work := func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		cancelled <- context.Cause(ctx)
+
+		return ctx.Err()
+	}
+
+	err := invoker.Run(context.Background(), invoker.MemoryGuardWith(sample, 350, time.Millisecond), work)
+	require.Equal(invoker.ErrMemoryExceeded, err)
+	require.Equal(invoker.ErrMemoryExceeded, <-cancelled)
+	require.Equal(uint64(400), atomic.LoadUint64(&usage))
+}
+
+// Test that MemoryGuard keeps polling the real heap while it's under the limit.
+func TestMemoryGuardUnder(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.MemoryGuard(1<<50, time.Millisecond), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+
+	// Any heap is more than one byte.
+	err = invoker.Run(context.Background(), invoker.MemoryGuard(1, time.Hour))
+	require.Equal(invoker.ErrMemoryExceeded, err)
+}
@@ -0,0 +1,122 @@
+package invoker
+
+// Mode is the state of a group of tasks.
+// A group starts in ModeInit, moves to ModeRun, ModeRace, ModeRepeat, ModeAny, or ModeRaceSuccess once started, and then to ModeDone.
+type Mode int
+
+const (
+	ModeInit Mode = iota
+	ModeRun
+	ModeRace
+	ModeRepeat
+	ModeAny
+	ModeRaceSuccess
+	ModeDone
+)
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeInit:
+		return "init"
+	case ModeRun:
+		return "run"
+	case ModeRace:
+		return "race"
+	case ModeRepeat:
+		return "repeat"
+	case ModeAny:
+		return "any"
+	case ModeRaceSuccess:
+		return "race-success"
+	case ModeDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// Subscribe returns a channel that receives each mode change, closed once the group is done.
+// The current mode is not sent, only transitions after the call.
+// A group only changes mode twice, so the channel is buffered to never block the tasks; subscribers may read at their leisure.
+// If the group has already finished, the channel is closed immediately.
+func (ts *Tasks) Subscribe() <-chan Mode {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ch := make(chan Mode, 2)
+
+	if ts.mode == modeDone {
+		close(ch)
+		return ch
+	}
+
+	ts.subscribers = append(ts.subscribers, ch)
+
+	return ch
+}
+
+// setMode changes the mode and notifies any subscribers.
+// The mutex must be held.
+func (ts *Tasks) setMode(m mode) {
+	ts.mode = m
+
+	for _, ch := range ts.subscribers {
+		select {
+		case ch <- Mode(m):
+		default:
+			// Should be impossible, but never block the state machine.
+		}
+
+		if m == modeDone {
+			close(ch)
+		}
+	}
+
+	if m == modeDone {
+		ts.subscribers = nil
+	}
+}
+
+// Status is a coarse view of the Mode, for callers that only care whether the group has started or finished.
+type Status int
+
+const (
+	// StatusInit means Run/Race/Repeat has not been called yet.
+	StatusInit Status = iota
+
+	// StatusRunning means Run/Race/Repeat has been called and not yet finished.
+	StatusRunning
+
+	// StatusDone means the group has finished and can't be run again.
+	StatusDone
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusInit:
+		return "init"
+	case StatusRunning:
+		return "running"
+	case StatusDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// Status returns the current status of the group.
+func (ts *Tasks) Status() Status {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	switch ts.mode {
+	case modeInit:
+		return StatusInit
+	case modeDone:
+		return StatusDone
+	default:
+		return StatusRunning
+	}
+}
@@ -0,0 +1,96 @@
+package invoker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// collect reads every mode until the channel is closed.
+func collect(ch <-chan invoker.Mode) (modes []invoker.Mode) {
+	for m := range ch {
+		modes = append(modes, m)
+	}
+
+	return modes
+}
+
+// Test the transitions for a normal run.
+func TestSubscribe(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New(invoker.Noop)
+	modes := tasks.Subscribe()
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal([]invoker.Mode{invoker.ModeRun, invoker.ModeDone}, collect(modes))
+}
+
+// Test the transitions for a cancelled race.
+func TestSubscribeCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tasks := invoker.New(invoker.Wait)
+	modes := tasks.Subscribe()
+
+	err := tasks.Race(ctx)
+	require.Equal(context.Canceled, err)
+	require.Equal([]invoker.Mode{invoker.ModeRace, invoker.ModeDone}, collect(modes))
+}
+
+// Test that subscribing after the group has finished returns a closed channel.
+func TestSubscribeFinished(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+	require.NoError(tasks.Run(context.Background()))
+
+	require.Empty(collect(tasks.Subscribe()))
+}
+
+// Test the names of each mode.
+func TestModeString(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal("init", invoker.ModeInit.String())
+	require.Equal("repeat", invoker.ModeRepeat.String())
+	require.Equal("any", invoker.ModeAny.String())
+	require.Equal("race-success", invoker.ModeRaceSuccess.String())
+	require.Equal("done", invoker.ModeDone.String())
+}
+
+// Test the status before, during, and after a run.
+func TestStatus(t *testing.T) {
+	require := require.New(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	tasks := invoker.New(func(ctx context.Context) (err error) {
+		close(started)
+		<-release
+
+		return nil
+	})
+
+	require.Equal(invoker.StatusInit, tasks.Status())
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tasks.Run(context.Background())
+	}()
+
+	<-started
+	require.Equal(invoker.StatusRunning, tasks.Status())
+
+	close(release)
+	require.NoError(<-errs)
+	require.Equal(invoker.StatusDone, tasks.Status())
+	require.Equal("done", tasks.Status().String())
+}
@@ -0,0 +1,63 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+)
+
+// Named returns a Task that prefixes any error with the name, ex. "database: connection refused".
+// The original error can still be matched with errors.Is and errors.As.
+func Named(name string, t Task) Task {
+	return func(ctx context.Context) (err error) {
+		err = t(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		return nil
+	}
+}
+
+// AddNamed adds a task like Add wrapped with Named, and records the name so it can be listed by Names.
+// Returns a handle that can cancel the task, like Add.
+// NOTE: Tasks are functions, so Add can't detect a Named task; use AddNamed instead.
+func (ts *Tasks) AddNamed(name string, t Task) (h *TaskHandle) {
+	h = new(TaskHandle)
+
+	h.err = ts.lockRoom(context.Background(), 1)
+	if h.err != nil {
+		return h
+	}
+
+	defer ts.mutex.Unlock()
+
+	if ts.closed {
+		// Dropped without a name, see Close.
+		return h
+	}
+
+	if ts.names == nil {
+		ts.names = make(map[int]string)
+	}
+
+	ts.names[ts.next] = name
+	ts.add(h.wrap(Named(name, t)), 1)
+
+	return h
+}
+
+// Names returns the name of each task in the order they were added.
+// The index matches the one passed to hooks like WithOnFinish, and is empty for tasks added without a name.
+func (ts *Tasks) Names() []string {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	names := make([]string, ts.next)
+	for index, name := range ts.names {
+		if index < ts.next {
+			names[index] = name
+		}
+	}
+
+	return names
+}
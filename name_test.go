@@ -0,0 +1,59 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the error is prefixed with the name.
+func TestNamed(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.Named("database", fail))
+	require.EqualError(err, "database: hello")
+	require.True(errors.Is(err, errSample))
+
+	err = invoker.Run(context.Background(), invoker.Named("database", invoker.Noop))
+	require.NoError(err)
+}
+
+// Test that the names are returned in the order the tasks were added.
+func TestNames(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+	tasks.AddNamed("database", invoker.Noop)
+	tasks.AddNamed("http", invoker.Noop)
+	tasks.Add(invoker.Noop)
+	tasks.AddNamed("metrics", invoker.Noop)
+
+	require.Equal([]string{"database", "http", "", "metrics"}, tasks.Names())
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Len(tasks.Names(), 4)
+}
+
+// Test that a task added after Close isn't named.
+func TestNamesClosed(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+
+	err := tasks.Close(context.Background(), false)
+	require.NoError(err)
+
+	tasks.AddNamed("dropped", invoker.Noop)
+	require.Empty(tasks.Names())
+}
@@ -0,0 +1,65 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrGraceExceeded is returned by DrainListener when connections are still active after the grace period.
+var ErrGraceExceeded = fmt.Errorf("grace period exceeded")
+
+// WaitPort returns a Task that blocks until a connection to the address succeeds, returning nil.
+// A dial is attempted every interval, with each attempt also limited to the interval.
+func WaitPort(network string, addr string, interval time.Duration) (t Task) {
+	return func(ctx context.Context) (err error) {
+		dialer := net.Dialer{Timeout: interval}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err == nil {
+				return conn.Close()
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// DrainListener returns a Task that closes the listener once the context is done, so no new connections are accepted.
+// It then waits up to the grace period for the active connections to finish, returning ErrGraceExceeded if they don't.
+// The counter should be incremented for each accepted connection and decremented once it's closed.
+// Otherwise the context error is returned, or the error from closing the listener.
+func DrainListener(lis net.Listener, active *Counter, grace time.Duration) (t Task) {
+	return func(ctx context.Context) (err error) {
+		<-ctx.Done()
+
+		// The listener may have already been closed by the server.
+		err = lis.Close()
+		if errors.Is(err, net.ErrClosed) {
+			err = nil
+		}
+
+		drain, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+
+		if active.WaitZero()(drain) != nil {
+			return ErrGraceExceeded
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return ctx.Err()
+	}
+}
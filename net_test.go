@@ -0,0 +1,147 @@
+package invoker_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the task returns once the listener is up.
+func TestWaitPort(t *testing.T) {
+	require := require.New(t)
+
+	// Reserve a port and then release it so the listener can be started later.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+
+	addr := lis.Addr().String()
+	require.NoError(lis.Close())
+
+	listen := func(ctx context.Context) (err error) {
+		err = invoker.Sleep(20 * time.Millisecond)(ctx)
+		if err != nil {
+			return err
+		}
+
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+
+		<-ctx.Done()
+		return lis.Close()
+	}
+
+	start := time.Now()
+
+	err = invoker.Race(context.Background(), invoker.WaitPort("tcp", addr, time.Millisecond), listen)
+	require.NoError(err)
+	require.True(time.Since(start) >= 20*time.Millisecond)
+}
+
+// Test that the task can be cancelled while the port is unavailable.
+func TestWaitPortCancel(t *testing.T) {
+	require := require.New(t)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+
+	addr := lis.Addr().String()
+	require.NoError(lis.Close())
+
+	err = invoker.Run(context.Background(), invoker.WaitPort("tcp", addr, time.Millisecond), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// fakeListener accepts nothing until it's closed.
+type fakeListener struct {
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newFakeListener() *fakeListener {
+	return &fakeListener{closed: make(chan struct{})}
+}
+
+func (l *fakeListener) Accept() (net.Conn, error) {
+	<-l.closed
+	return nil, net.ErrClosed
+}
+
+func (l *fakeListener) Close() (err error) {
+	err = net.ErrClosed
+
+	l.once.Do(func() {
+		close(l.closed)
+		err = nil
+	})
+
+	return err
+}
+
+func (l *fakeListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
+
+// Test that DrainListener stops accepting on cancel and waits for the active connections.
+func TestDrainListener(t *testing.T) {
+	require := require.New(t)
+
+	lis := newFakeListener()
+
+	var active invoker.Counter
+	active.Inc()
+	active.Inc()
+
+	// A lingering connection finishes shortly after the shutdown.
+	conn := func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		active.Dec()
+
+		time.Sleep(10 * time.Millisecond)
+		active.Dec()
+
+		return nil
+	}
+
+	start := time.Now()
+
+	err := invoker.Run(context.Background(), invoker.DrainListener(lis, &active, time.Second), conn, invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.True(time.Since(start) >= 10*time.Millisecond)
+	require.Equal(0, active.Count())
+
+	// The listener was closed.
+	_, err = lis.Accept()
+	require.Equal(net.ErrClosed, err)
+}
+
+// Test that DrainListener returns ErrGraceExceeded if connections linger past the grace period.
+func TestDrainListenerGrace(t *testing.T) {
+	require := require.New(t)
+
+	lis := newFakeListener()
+
+	var active invoker.Counter
+	active.Inc()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+
+	err := invoker.DrainListener(lis, &active, 10*time.Millisecond)(ctx)
+	require.Equal(invoker.ErrGraceExceeded, err)
+	require.True(time.Since(start) >= 10*time.Millisecond)
+
+	// Closing an already closed listener is fine once the connections are gone.
+	active.Dec()
+
+	err = invoker.DrainListener(lis, &active, 10*time.Millisecond)(ctx)
+	require.Equal(context.Canceled, err)
+}
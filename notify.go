@@ -0,0 +1,49 @@
+package invoker
+
+import (
+	"context"
+	"sync"
+)
+
+// Notifier wakes any number of waiting tasks on each Broadcast, like a context-aware sync.Cond.
+// The zero value is ready to use.
+type Notifier struct {
+	mutex sync.Mutex
+	ch    chan struct{}
+}
+
+// Broadcast wakes every task currently waiting.
+// Tasks that start waiting afterwards will wait for the next broadcast.
+func (n *Notifier) Broadcast() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+}
+
+// Wait returns a Task that blocks until the next Broadcast, returning nil.
+func (n *Notifier) Wait() (t Task) {
+	return func(ctx context.Context) (err error) {
+		select {
+		case <-n.wait():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// wait returns the channel closed by the next broadcast.
+func (n *Notifier) wait() <-chan struct{} {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+
+	return n.ch
+}
@@ -0,0 +1,51 @@
+package invoker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a single broadcast wakes every waiter.
+func TestNotifier(t *testing.T) {
+	require := require.New(t)
+
+	var n invoker.Notifier
+
+	var ready sync.WaitGroup
+	ready.Add(3)
+
+	waiter := func(ctx context.Context) (err error) {
+		ready.Done()
+		return n.Wait()(ctx)
+	}
+
+	go func() {
+		ready.Wait()
+
+		// Give the waiters a moment to block.
+		time.Sleep(10 * time.Millisecond)
+		n.Broadcast()
+	}()
+
+	err := invoker.Run(context.Background(), waiter, waiter, waiter)
+	require.NoError(err)
+}
+
+// Test that a broadcast before waiting is not remembered.
+func TestNotifierMissed(t *testing.T) {
+	require := require.New(t)
+
+	var n invoker.Notifier
+	n.Broadcast()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, n.Wait())
+	require.Equal(context.Canceled, err)
+}
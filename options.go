@@ -0,0 +1,90 @@
+package invoker
+
+import "time"
+
+// Option configures a Tasks instance.
+type Option func(ts *Tasks)
+
+// Apply configures the Tasks with the given options, returning itself for chaining.
+// Options should be applied before calling Run/Race/Repeat.
+func (ts *Tasks) Apply(opts ...Option) *Tasks {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	for _, opt := range opts {
+		opt(ts)
+	}
+
+	return ts
+}
+
+// WithLimit restricts the number of tasks that can run at the same time.
+// Any additional tasks are queued and started in order as running tasks finish.
+// Each task counts as one towards the limit unless added with AddWeighted.
+// A limit of zero means unlimited.
+func WithLimit(n int) Option {
+	return func(ts *Tasks) {
+		ts.limit = int64(n)
+	}
+}
+
+// AllowEmpty makes Repeat return nil immediately when there are no tasks, like the other modes.
+// An empty Run/Race/Any/RaceSuccess always returns nil, but by default an empty Repeat blocks until the context is done.
+// That allows tasks to be added later, while this is for callers that conditionally build the tasks and sometimes have none.
+// Like the other modes, any tasks added after an empty Repeat has returned are started with a cancelled context.
+func AllowEmpty() Option {
+	return func(ts *Tasks) {
+		ts.allowEmpty = true
+	}
+}
+
+// WithCancelIsSuccess returns nil instead of context.Canceled, for tasks that run until told to stop.
+// By default this only applies when the group cancelled itself, ex. a task used a cancelled context.
+// If external is true, this also applies when the context provided to Run/Race/Repeat was cancelled.
+func WithCancelIsSuccess(external bool) Option {
+	return func(ts *Tasks) {
+		ts.cancelSuccess = true
+		ts.cancelExternal = external
+	}
+}
+
+// WithTiming calls the function after each task returns with how long it ran.
+// The index is the order the task was added, starting at zero.
+// The function is called from the task's goroutine and must be safe for concurrent use.
+// It's called before the task is considered finished, so it may call Add.
+func WithTiming(fn func(index int, d time.Duration, err error)) Option {
+	return func(ts *Tasks) {
+		ts.timing = fn
+	}
+}
+
+// WithOnFinish calls the function after each task returns.
+// The index is the order the task was added, starting at zero.
+// The function is called from the task's goroutine and must be safe for concurrent use.
+// It's called before the task is considered finished, so it may call Add to schedule more work.
+func WithOnFinish(fn func(index int, err error)) Option {
+	return func(ts *Tasks) {
+		ts.onFinish = fn
+	}
+}
+
+// RaceIgnore prevents results matching the predicate from winning a Race.
+// The race continues until a result that doesn't match, or the first ignored result is returned if every result matched.
+// Ignored results that lost the race are returned as extra errors by RaceVerbose.
+func RaceIgnore(fn func(err error) bool) Option {
+	return func(ts *Tasks) {
+		ts.raceIgnore = fn
+	}
+}
+
+// WithRaceLoser calls the function for each task that didn't win a Race or Any, as it finishes.
+// This allows cleaning up anything the losing tasks produced, ex. closing a connection they opened.
+// For Any, the tasks that failed before the success are reported once it succeeds.
+// The index is the order the task was added, starting at zero.
+// The function is called without the group locked, so it may call Add, and calls are never concurrent.
+// Every loser is reported before Race or Any returns.
+func WithRaceLoser(fn func(index int, err error)) Option {
+	return func(ts *Tasks) {
+		ts.raceLoser = fn
+	}
+}
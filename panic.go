@@ -0,0 +1,171 @@
+package invoker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// MaxStackBytes caps the size of the stack trace captured in ErrPanic, or 0 for unlimited.
+// The stack is truncated at a line boundary where possible, keeping the top frames.
+// This bounds memory and log volume during a storm of panics; it should be set before any tasks are run.
+var MaxStackBytes = 0
+
+// Recover returns a Task that converts a panic into an ErrPanic error.
+// Invoker does not catch panics by default, so this should only be used for tasks that are allowed to panic.
+func Recover(t Task) (t2 Task) {
+	return RecoverWith(nil, t)
+}
+
+// RecoverWith returns a Task like Recover, but also calls logFn with the task's context at the moment of recovery.
+// This allows logging the panic with contextual values, ex. a request ID, that aren't visible to the caller.
+// The panic is still returned as an ErrPanic, so it won't be recovered again by WithPanicLimit or an outer Recover.
+func RecoverWith(logFn func(ctx context.Context, p interface{}, stack []byte), t Task) (t2 Task) {
+	return recoverTask(t, logFn, false)
+}
+
+// RecoverFull returns a Task like Recover, but also captures the stack of every goroutine, see ErrPanic.Dump.
+// This is the equivalent of WithFullDumpOnPanic for tasks that are individually wrapped with Recover.
+func RecoverFull(t Task) (t2 Task) {
+	return recoverTask(t, nil, true)
+}
+
+// WithFullDumpOnPanic captures the stack of every goroutine when WithPanicLimit recovers a panic, see ErrPanic.Dump.
+// This is useful when a panic was caused by bad state in another task, but is expensive so it's disabled by default.
+// It only applies to panics recovered by WithPanicLimit; use RecoverFull for tasks wrapped with Recover.
+func WithFullDumpOnPanic() Option {
+	return func(ts *Tasks) {
+		ts.fullDump = true
+	}
+}
+
+// recoverTask converts a panic into an ErrPanic, optionally logging it and capturing every goroutine.
+func recoverTask(t Task, logFn func(ctx context.Context, p interface{}, stack []byte), full bool) (t2 Task) {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			ep := ErrPanic{value: p, stack: stack()}
+			if full {
+				ep.dump = dump()
+			}
+
+			if logFn != nil {
+				logFn(ctx, p, ep.stack)
+			}
+
+			err = ep
+		}()
+
+		return t(ctx)
+	}
+}
+
+// stack returns the stack trace of the current goroutine, truncated to MaxStackBytes.
+func stack() []byte {
+	buf := debug.Stack()
+
+	limit := MaxStackBytes
+	if limit <= 0 || len(buf) <= limit {
+		return buf
+	}
+
+	buf = buf[:limit]
+
+	// Truncate at the last full line, unless the first line is already too long.
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i+1]
+	}
+
+	return buf
+}
+
+// dump returns the stack trace of every goroutine.
+func dump() []byte {
+	buf := make([]byte, 64*1024)
+
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// RecoverIf returns a Task like Recover, but only recovers panics matching the predicate.
+// Any other panic is re-raised with the same value, so genuine bugs still crash.
+func RecoverIf(pred func(p interface{}) bool, t Task) (t2 Task) {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			if !pred(p) {
+				panic(p)
+			}
+
+			err = ErrPanic{value: p, stack: stack()}
+		}()
+
+		return t(ctx)
+	}
+}
+
+// ErrPanic is returned with the recovered panic value and stack trace.
+type ErrPanic struct {
+	value interface{}
+	stack []byte
+	dump  []byte
+}
+
+func (ep ErrPanic) Error() string {
+	return fmt.Sprintf("recovered panic: %v", ep.value)
+}
+
+// Value returns the value passed to panic.
+func (ep ErrPanic) Value() interface{} {
+	return ep.value
+}
+
+// Stack returns the raw stack trace captured during recovery.
+func (ep ErrPanic) Stack() []byte {
+	return ep.stack
+}
+
+// Dump returns the stack trace of every goroutine captured during recovery, or nil unless WithFullDumpOnPanic was used.
+func (ep ErrPanic) Dump() []byte {
+	return ep.dump
+}
+
+// CleanStack returns the stack trace without the frames used to recover the panic.
+// The first frame is the function that panicked.
+func (ep ErrPanic) CleanStack() string {
+	stack := string(ep.stack)
+
+	// The first line is the goroutine header, ex. "goroutine 1 [running]:"
+	header, frames, ok := strings.Cut(stack, "\n")
+	if !ok {
+		return stack
+	}
+
+	// Each frame is two lines: the function and the file:line.
+	// Skip everything up to and including the call to panic.
+	lines := strings.Split(frames, "\n")
+	for i := 0; i+1 < len(lines); i += 2 {
+		if strings.HasPrefix(lines[i], "panic(") {
+			return header + "\n" + strings.Join(lines[i+2:], "\n")
+		}
+	}
+
+	return stack
+}
@@ -0,0 +1,221 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+func panicky(ctx context.Context) (err error) {
+	panic("hello")
+}
+
+// Test that a panic is converted into an error.
+func TestRecover(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.Recover(panicky))
+	require.IsType(invoker.ErrPanic{}, err)
+
+	ep := err.(invoker.ErrPanic)
+	require.Equal("hello", ep.Value())
+	require.Contains(string(ep.Stack()), "runtime/debug.Stack")
+}
+
+// Test that the clean stack starts with the function that panicked.
+func TestRecoverCleanStack(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.Recover(panicky))
+	require.IsType(invoker.ErrPanic{}, err)
+
+	stack := err.(invoker.ErrPanic).CleanStack()
+	lines := strings.Split(stack, "\n")
+
+	require.True(strings.HasPrefix(lines[0], "goroutine "))
+	require.True(strings.HasPrefix(lines[1], "github.com/kixelated/invoker_test.panicky("), lines[1])
+	require.NotContains(stack, "runtime/debug.Stack")
+}
+
+type requestKey struct{}
+
+// Test that the log function receives the task's context and stack.
+func TestRecoverWith(t *testing.T) {
+	require := require.New(t)
+
+	var logged interface{}
+	var loggedValue interface{}
+	var loggedStack []byte
+
+	logFn := func(ctx context.Context, p interface{}, stack []byte) {
+		logged = ctx.Value(requestKey{})
+		loggedValue = p
+		loggedStack = stack
+	}
+
+	ctx := context.WithValue(context.Background(), requestKey{}, "abc123")
+
+	err := invoker.Run(ctx, invoker.RecoverWith(logFn, panicky))
+	require.IsType(invoker.ErrPanic{}, err)
+
+	require.Equal("abc123", logged)
+	require.Equal("hello", loggedValue)
+	require.Contains(string(loggedStack), "panicky")
+	require.Equal(err.(invoker.ErrPanic).Stack(), loggedStack)
+}
+
+// Test that an outer Recover doesn't recover the panic again.
+func TestRecoverWithNested(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	logFn := func(ctx context.Context, p interface{}, stack []byte) {
+		count += 1
+	}
+
+	err := invoker.Run(context.Background(), invoker.Recover(invoker.RecoverWith(logFn, panicky)))
+	require.IsType(invoker.ErrPanic{}, err)
+	require.Equal("hello", err.(invoker.ErrPanic).Value())
+	require.Equal(1, count)
+}
+
+// Test that only matching panics are recovered.
+func TestRecoverIf(t *testing.T) {
+	require := require.New(t)
+
+	pred := func(p interface{}) bool {
+		return p == "hello"
+	}
+
+	err := invoker.Run(context.Background(), invoker.RecoverIf(pred, panicky))
+	require.IsType(invoker.ErrPanic{}, err)
+	require.Equal("hello", err.(invoker.ErrPanic).Value())
+
+	other := func(ctx context.Context) (err error) {
+		panic("bug")
+	}
+
+	// Call directly, since a panic in the group's goroutine would crash the test.
+	require.PanicsWithValue("bug", func() {
+		_ = invoker.RecoverIf(pred, other)(context.Background())
+	})
+}
+
+// Test that the full dump includes the other goroutines.
+func TestFullDumpOnPanic(t *testing.T) {
+	require := require.New(t)
+
+	started := make(chan struct{})
+	sibling := func(ctx context.Context) (err error) {
+		close(started)
+		return invoker.Wait(ctx)
+	}
+
+	// Panic once the sibling is running so it's in the dump.
+	f := func(ctx context.Context) (err error) {
+		<-started
+		return panicky(ctx)
+	}
+
+	tasks := invoker.New(sibling, f).Apply(invoker.WithPanicLimit(1), invoker.WithFullDumpOnPanic())
+
+	err := tasks.Run(context.Background())
+	require.IsType(invoker.ErrPanic{}, err)
+
+	dump := string(err.(invoker.ErrPanic).Dump())
+	require.True(strings.Count(dump, "goroutine ") > 1, dump)
+	require.Contains(dump, "panicky")
+}
+
+// Test that RecoverFull captures the full dump without WithPanicLimit.
+func TestRecoverFull(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.RecoverFull(panicky))
+	require.IsType(invoker.ErrPanic{}, err)
+
+	dump := string(err.(invoker.ErrPanic).Dump())
+	require.Contains(dump, "panicky")
+}
+
+// Test that the full dump is not captured by default.
+func TestFullDumpOnPanicDefault(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.Recover(panicky))
+	require.IsType(invoker.ErrPanic{}, err)
+	require.Nil(err.(invoker.ErrPanic).Dump())
+}
+
+// Test that MaxStackBytes truncates the captured stack at a line boundary.
+func TestMaxStackBytes(t *testing.T) {
+	require := require.New(t)
+
+	defer func(limit int) { invoker.MaxStackBytes = limit }(invoker.MaxStackBytes)
+	invoker.MaxStackBytes = 200
+
+	err := invoker.Run(context.Background(), invoker.Recover(panicky))
+	require.IsType(invoker.ErrPanic{}, err)
+
+	stack := err.(invoker.ErrPanic).Stack()
+	require.True(len(stack) <= 200, "stack is %d bytes", len(stack))
+	require.True(strings.HasPrefix(string(stack), "goroutine "))
+	require.True(strings.HasSuffix(string(stack), "\n"))
+}
+
+// Test that MaxStackBytes cuts mid-line when the first line exceeds the cap.
+func TestMaxStackBytesSmall(t *testing.T) {
+	require := require.New(t)
+
+	defer func(limit int) { invoker.MaxStackBytes = limit }(invoker.MaxStackBytes)
+	invoker.MaxStackBytes = 5
+
+	err := invoker.Run(context.Background(), invoker.Recover(panicky))
+	require.IsType(invoker.ErrPanic{}, err)
+	require.Equal("gorou", string(err.(invoker.ErrPanic).Stack()))
+}
+
+// Test that a panic is reported even if another task errored first.
+func TestPanicPrecedence(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	failed := make(chan struct{})
+	fail := func(ctx context.Context) (err error) {
+		close(failed)
+		return errSample
+	}
+
+	crash := func(ctx context.Context) (err error) {
+		<-failed
+		panic("hello")
+	}
+
+	for i := 0; i < 20; i += 1 {
+		failed = make(chan struct{})
+
+		err := invoker.New(fail, crash).Apply(invoker.WithPanicLimit(1)).Run(context.Background())
+		require.IsType(invoker.ErrPanic{}, err)
+	}
+}
+
+// Test that a panic is preferred over a cancellation that was returned first.
+func TestPanicPrecedenceCancel(t *testing.T) {
+	require := require.New(t)
+
+	crash := func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		panic("hello")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.New(invoker.Wait, crash).Apply(invoker.WithPanicLimit(1)).Run(ctx)
+	require.IsType(invoker.ErrPanic{}, err)
+}
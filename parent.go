@@ -0,0 +1,12 @@
+package invoker
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrParentExited is returned by UntilParentExit when the parent process has exited.
+var ErrParentExited = fmt.Errorf("parent process exited")
+
+// parentPoll is how often UntilParentExit checks the parent process.
+const parentPoll = 100 * time.Millisecond
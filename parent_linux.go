@@ -0,0 +1,34 @@
+//go:build linux
+
+package invoker
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// UntilParentExit returns a Task that blocks until the parent process exits, returning ErrParentExited.
+// This allows a sidecar or subprocess to shut itself down instead of being orphaned.
+// The parent is polled, detecting when the process is reparented to init or a subreaper.
+// This is only supported on Linux; other platforms return errors.ErrUnsupported.
+func UntilParentExit() (t Task) {
+	return func(ctx context.Context) (err error) {
+		parent := os.Getppid()
+
+		ticker := time.NewTicker(parentPoll)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+
+			if os.Getppid() != parent {
+				return ErrParentExited
+			}
+		}
+	}
+}
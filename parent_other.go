@@ -0,0 +1,17 @@
+//go:build !linux
+
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// UntilParentExit returns a Task that blocks until the parent process exits, returning ErrParentExited.
+// This is only supported on Linux; this platform returns errors.ErrUnsupported.
+func UntilParentExit() (t Task) {
+	return func(ctx context.Context) (err error) {
+		return fmt.Errorf("parent exit detection: %w", errors.ErrUnsupported)
+	}
+}
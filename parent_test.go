@@ -0,0 +1,46 @@
+//go:build linux
+
+package invoker_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that an orphaned process detects that its parent exited.
+func TestUntilParentExit(t *testing.T) {
+	require := require.New(t)
+
+	if os.Getenv("INVOKER_ORPHAN") == "1" {
+		// Running as the orphan, see below.
+		err := invoker.Run(context.Background(), invoker.UntilParentExit(), invoker.Timeout(5*time.Second))
+		if err == invoker.ErrParentExited {
+			os.Stdout.WriteString("parent exited\n")
+		}
+
+		return
+	}
+
+	// Start this test again in the background of a shell that exits shortly afterwards, orphaning it.
+	// The output is only closed once the orphan exits, as it inherits stdout.
+	cmd := exec.Command("sh", "-c", `"$0" -test.run='^TestUntilParentExit$' & sleep 1`, os.Args[0])
+	cmd.Env = append(os.Environ(), "INVOKER_ORPHAN=1")
+
+	out, err := cmd.Output()
+	require.NoError(err)
+	require.Contains(string(out), "parent exited")
+}
+
+// Test that the task can be cancelled while the parent is alive.
+func TestUntilParentExitCancel(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.UntilParentExit(), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
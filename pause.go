@@ -0,0 +1,33 @@
+package invoker
+
+// Pause prevents any queued or newly added tasks from starting until Resume is called.
+// Tasks that are already running are not affected.
+// If the group is cancelled while paused, the queued tasks are started so they can return.
+func (ts *Tasks) Pause() {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.paused = true
+}
+
+// Resume starts any tasks that were held while paused.
+func (ts *Tasks) Resume() {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.paused = false
+
+	if ts.mode != modeInit && ts.mode != modeDone {
+		ts.dequeue()
+	}
+}
+
+// unpause starts any paused tasks after the group has been cancelled.
+func (ts *Tasks) unpause() {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.mode != modeDone {
+		ts.dequeue()
+	}
+}
@@ -0,0 +1,59 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that tasks added while paused don't start until resumed.
+func TestPause(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	tasks.Add(func(ctx context.Context) (err error) {
+		tasks.Pause()
+		tasks.Add(f, f)
+
+		time.Sleep(10 * time.Millisecond)
+		if atomic.LoadUint64(&count) != 0 {
+			return fmt.Errorf("task started while paused")
+		}
+
+		tasks.Resume()
+		return nil
+	})
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(2), atomic.LoadUint64(&count))
+}
+
+// Test that the group finishes when cancelled while paused.
+func TestPauseCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tasks := invoker.New()
+	tasks.Pause()
+	tasks.Add(invoker.Wait)
+
+	go cancel()
+
+	err := tasks.Run(ctx)
+	require.Equal(context.Canceled, err)
+}
@@ -0,0 +1,53 @@
+package invoker
+
+import (
+	"sort"
+)
+
+// Pending returns a copy of the tasks that have been added but not started, in the order they would start.
+// This allows a scheduler to inspect the queue, ex. to persist a description of the remaining work.
+func (ts *Tasks) Pending() (tasks []Task) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	// The queue is a heap, so sort a copy to get the start order.
+	sorted := make(queue, len(ts.pending))
+	copy(sorted, ts.pending)
+	sort.Sort(sorted)
+
+	tasks = make([]Task, len(sorted))
+	for i, j := range sorted {
+		tasks[i] = j.task
+	}
+
+	return tasks
+}
+
+// SetPending replaces any pending tasks with the given tasks, ex. when reconstructing work from storage.
+// This is only allowed before Run/Race/Repeat; ErrRunning or ErrFinished is returned otherwise, or ErrClosed after Close.
+// The replaced tasks are never run, so their names are no longer listed by Names and their handles have no effect.
+func (ts *Tasks) SetPending(tasks []Task) (err error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	switch {
+	case ts.closed:
+		return ErrClosed
+	case ts.mode == modeDone:
+		return ErrFinished
+	case ts.mode != modeInit:
+		return ErrRunning
+	}
+
+	for _, j := range ts.pending {
+		delete(ts.names, j.index)
+	}
+
+	ts.pending = nil
+
+	for _, t := range tasks {
+		ts.add(t, 1)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package invoker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the pending tasks can be read and replaced before Run.
+func TestPending(t *testing.T) {
+	require := require.New(t)
+
+	var mutex sync.Mutex
+	var order []string
+
+	tasks := invoker.New(record(&mutex, &order, "old"))
+	tasks.AddPriority(1, record(&mutex, &order, "urgent"))
+
+	pending := tasks.Pending()
+	require.Equal(2, len(pending))
+
+	// Returned in start order, so the urgent task is first.
+	require.NoError(pending[0](context.Background()))
+	require.Equal([]string{"urgent"}, order)
+	order = nil
+
+	err := tasks.SetPending([]invoker.Task{record(&mutex, &order, "a"), record(&mutex, &order, "b")})
+	require.NoError(err)
+	require.Equal(2, len(tasks.Pending()))
+
+	err = tasks.Apply(invoker.WithLimit(1)).Run(context.Background())
+	require.NoError(err)
+	require.Equal([]string{"a", "b"}, order)
+	require.Equal(0, len(tasks.Pending()))
+}
+
+// Test that the pending tasks can't be replaced once running or finished.
+func TestSetPendingRunning(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+
+	check := func(ctx context.Context) (err error) {
+		return tasks.SetPending(nil)
+	}
+
+	tasks.Add(check)
+
+	err := tasks.Run(context.Background())
+	require.Equal(invoker.ErrRunning, err)
+
+	err = tasks.SetPending(nil)
+	require.Equal(invoker.ErrFinished, err)
+}
+
+// Test that replaced tasks are no longer named.
+func TestSetPendingNames(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+	tasks.AddNamed("old", invoker.Noop)
+
+	err := tasks.SetPending([]invoker.Task{invoker.Noop})
+	require.NoError(err)
+
+	tasks.AddNamed("new", invoker.Noop)
+	require.Equal([]string{"", "", "new"}, tasks.Names())
+}
@@ -0,0 +1,166 @@
+package invoker
+
+import (
+	"context"
+	"sync"
+)
+
+// Phases adds tasks to a group with a shutdown phase, so they're cancelled in order instead of all at once.
+// When the group is cancelled, the tasks in the lowest phase are cancelled first and every one must return before the next phase is cancelled.
+// ex. phase 0 stops accepting requests, phase 1 drains the queue, and phase 2 closes the database.
+//
+// Tasks added directly to the group are cancelled immediately as usual, before any phase.
+// If the phased tasks return on their own without the group being cancelled, the phases have no effect.
+// NOTE: A phased task ignores the group cancellation until its phase, so WithCancelTimeout should be used to bound the shutdown.
+// NOTE: An extra task is added to the group to coordinate the phases, which counts towards WithLimit.
+type Phases struct {
+	ts *Tasks
+
+	mutex  sync.Mutex
+	phases map[int]*phase
+
+	// the number of phased tasks that haven't returned
+	total Counter
+
+	// true while the watch task is running
+	watching bool
+}
+
+// phase is the tasks for a single shutdown phase.
+type phase struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	running Counter
+}
+
+// Phases returns the shutdown phases for the group, creating them on first use.
+func (ts *Tasks) Phases() (p *Phases) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.phases == nil {
+		ts.phases = &Phases{ts: ts, phases: make(map[int]*phase)}
+	}
+
+	return ts.phases
+}
+
+// Add adds tasks to the group that are cancelled during the given shutdown phase, returning itself for chaining.
+// Tasks added to a phase that has already been cancelled start with a cancelled context.
+func (p *Phases) Add(n int, tasks ...Task) *Phases {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	ph, ok := p.phases[n]
+	if !ok {
+		ph = new(phase)
+		ph.ctx, ph.cancel = context.WithCancelCause(context.Background())
+		p.phases[n] = ph
+	}
+
+	p.ts.mutex.Lock()
+	defer p.ts.mutex.Unlock()
+
+	if p.ts.closed {
+		// Dropped, see Close.
+		return p
+	}
+
+	for _, t := range tasks {
+		p.total.Inc()
+		ph.running.Inc()
+
+		p.ts.add(p.wrap(ph, t), 1)
+	}
+
+	if !p.watching {
+		p.watching = true
+		p.ts.add(p.watch, 1)
+	}
+
+	return p
+}
+
+// wrap runs the task with a context that is only cancelled during its phase.
+func (p *Phases) wrap(ph *phase, t Task) Task {
+	return func(ctx context.Context) (err error) {
+		defer p.total.Dec()
+		defer ph.running.Dec()
+
+		ctx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+		defer cancel(nil)
+
+		stop := context.AfterFunc(ph.ctx, func() {
+			cancel(context.Cause(ph.ctx))
+		})
+		defer stop()
+
+		return t(ctx)
+	}
+}
+
+// watch blocks until the group is cancelled, then cancels each phase in order.
+// It returns nil early if every phased task has returned on its own.
+func (p *Phases) watch(ctx context.Context) (err error) {
+	for {
+		err = p.total.WaitZero()(ctx)
+		if err != nil {
+			break
+		}
+
+		p.mutex.Lock()
+
+		// Check again now that Add can't race with us.
+		if p.total.Count() == 0 {
+			p.watching = false
+			p.mutex.Unlock()
+
+			return nil
+		}
+
+		p.mutex.Unlock()
+	}
+
+	cause := context.Cause(ctx)
+
+	for {
+		ph := p.next(cause)
+		if ph == nil {
+			break
+		}
+
+		// The phases are cancelled in order, so don't stop waiting because the group was.
+		_ = ph.running.WaitZero()(context.Background())
+	}
+
+	return err
+}
+
+// next cancels the lowest phase that hasn't been cancelled yet.
+// If there are none, it returns nil and any phases added from now on need another watch task.
+func (p *Phases) next(cause error) (ph *phase) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	lowest := 0
+
+	for n, candidate := range p.phases {
+		if candidate.ctx.Err() != nil {
+			continue
+		}
+
+		if ph == nil || n < lowest {
+			ph = candidate
+			lowest = n
+		}
+	}
+
+	if ph == nil {
+		p.watching = false
+		return nil
+	}
+
+	ph.cancel(cause)
+
+	return ph
+}
@@ -0,0 +1,138 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// events records the order things happened across tasks.
+type events struct {
+	mutex sync.Mutex
+	list  []string
+}
+
+func (e *events) add(event string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.list = append(e.list, event)
+}
+
+// phased returns a task that records when it's cancelled and when it returns, lingering in between.
+func (e *events) phased(name string, linger time.Duration) invoker.Task {
+	return func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		e.add("stop " + name)
+
+		time.Sleep(linger)
+		e.add("done " + name)
+
+		return ctx.Err()
+	}
+}
+
+// Test that the phases are cancelled in order on cancellation, each waiting for the previous to return.
+func TestPhases(t *testing.T) {
+	require := require.New(t)
+
+	var e events
+
+	tasks := invoker.New(invoker.Timeout(time.Millisecond))
+	tasks.Phases().
+		Add(2, e.phased("close", 0)).
+		Add(0, e.phased("intake", 10*time.Millisecond)).
+		Add(1, e.phased("drain", 5*time.Millisecond))
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.DeadlineExceeded, err)
+
+	require.Equal([]string{
+		"stop intake", "done intake",
+		"stop drain", "done drain",
+		"stop close", "done close",
+	}, e.list)
+}
+
+// Test that every task in a phase returns before the next phase is cancelled.
+func TestPhasesMultiple(t *testing.T) {
+	require := require.New(t)
+
+	var e events
+
+	tasks := invoker.New()
+	tasks.Phases().
+		Add(0, e.phased("fast", 0), e.phased("slow", 10*time.Millisecond)).
+		Add(1, e.phased("last", 0))
+
+	// Unphased tasks are cancelled immediately.
+	tasks.Add(invoker.Timeout(time.Millisecond), func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		e.add("stop unphased")
+
+		return ctx.Err()
+	})
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.DeadlineExceeded, err)
+
+	require.Len(e.list, 7)
+	require.Equal([]string{"stop last", "done last"}, e.list[5:])
+
+	// The slow task in the first phase holds up the next phase, while the unphased task was stopped right away.
+	require.Contains(e.list[:5], "stop unphased")
+	require.Contains(e.list[:5], "done slow")
+}
+
+// Test that an error from a phased task starts the phased shutdown.
+func TestPhasesError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	var e events
+
+	tasks := invoker.New()
+	tasks.Phases().
+		Add(0, func(ctx context.Context) (err error) {
+			return errSample
+		}).
+		Add(1, e.phased("drain", 0))
+
+	err := tasks.Run(context.Background())
+	require.Equal(errSample, err)
+	require.Equal([]string{"stop drain", "done drain"}, e.list)
+}
+
+// Test that the phases have no effect when the tasks return on their own.
+func TestPhasesComplete(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	var mutex sync.Mutex
+
+	f := func(ctx context.Context) (err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// Not cancelled, even though other tasks have finished.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		count += 1
+		return nil
+	}
+
+	tasks := invoker.New()
+	tasks.Phases().Add(0, f, f).Add(1, f)
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(3, count)
+}
@@ -0,0 +1,194 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrProbeFailed is returned by UntilTimed when the condition isn't true before the timeout.
+var ErrProbeFailed = fmt.Errorf("probe failed: %w", context.DeadlineExceeded)
+
+// Until returns a Task that blocks until the condition is true, returning nil.
+// The condition is checked immediately and then every interval, returning early if it errors.
+func Until(interval time.Duration, cond func(ctx context.Context) (ok bool, err error)) (t Task) {
+	return func(ctx context.Context) (err error) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			ok, err := cond(ctx)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// UntilTimed returns a Task that blocks until the condition is true like Until, returning ErrProbeFailed after the timeout.
+// The elapsed function returns how long the last run waited, ex. to report startup latency for a dependency.
+// It returns zero until the task has returned at least once.
+func UntilTimed(cond func(ctx context.Context) (ok bool, err error), interval time.Duration, timeout time.Duration) (t Task, elapsed func() time.Duration) {
+	var mutex sync.Mutex
+	var took time.Duration
+
+	elapsed = func() time.Duration {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return took
+	}
+
+	t = func(ctx context.Context) (err error) {
+		start := time.Now()
+
+		defer func() {
+			mutex.Lock()
+			took = time.Since(start)
+			mutex.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeoutCause(ctx, timeout, ErrProbeFailed)
+		defer cancel()
+
+		err = Until(interval, cond)(ctx)
+		if errors.Is(err, context.DeadlineExceeded) && context.Cause(ctx) == ErrProbeFailed {
+			return ErrProbeFailed
+		}
+
+		return err
+	}
+
+	return t, elapsed
+}
+
+// BackoffOption configures UntilBackoff.
+type BackoffOption func(b *backoff)
+
+type backoff struct {
+	timer func(d time.Duration) Task
+}
+
+// WithBackoffTimer replaces Timer for waiting between checks, ex. to record the delays in a test without waiting.
+func WithBackoffTimer(timer func(d time.Duration) Task) BackoffOption {
+	return func(b *backoff) {
+		b.timer = timer
+	}
+}
+
+// UntilBackoff returns a Task that blocks until the condition is true, returning nil.
+// The condition is checked immediately, then after waiting base, doubling the wait each time up to limit.
+// This reduces the load on a slow dependency during a long wait, returning early if the condition errors.
+// A base below a millisecond is raised to a millisecond to avoid spinning, and a limit below the base is raised to the base.
+func UntilBackoff(cond func(ctx context.Context) (ok bool, err error), base time.Duration, limit time.Duration, opts ...BackoffOption) (t Task) {
+	b := backoff{timer: Timer}
+	for _, opt := range opts {
+		opt(&b)
+	}
+
+	base = max(base, time.Millisecond)
+	limit = max(limit, base)
+
+	return func(ctx context.Context) (err error) {
+		delay := base
+
+		for {
+			ok, err := cond(ctx)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				return nil
+			}
+
+			err = b.timer(delay)(ctx)
+			if err != nil {
+				return err
+			}
+
+			delay = min(2*delay, limit)
+		}
+	}
+}
+
+// UntilBelow returns a Task that blocks until the sample is below the threshold, returning nil.
+// The sample is checked immediately and then every interval, ex. the load average or a custom gauge.
+// This can be used to pause new work while the system is under pressure.
+func UntilBelow(threshold float64, sample func() float64, interval time.Duration) (t Task) {
+	return UntilGauge(sample, func(v float64) bool {
+		return v < threshold
+	}, interval)
+}
+
+// UntilGauge returns a Task that blocks until the gauge satisfies the condition, returning nil.
+// The gauge is checked immediately and then every poll interval, ex. until a queue depth is below a limit.
+func UntilGauge(get func() float64, cond func(v float64) bool, poll time.Duration) (t Task) {
+	return Until(poll, func(ctx context.Context) (ok bool, err error) {
+		return cond(get()), nil
+	})
+}
+
+// UntilFlag returns a Task that blocks until the flag is true, returning nil.
+// The flag is checked immediately and then every poll interval, which should be small.
+// This bridges existing code using atomic flags without refactoring it to use channels.
+func UntilFlag(flag *atomic.Bool, poll time.Duration) (t Task) {
+	return Until(poll, func(ctx context.Context) (ok bool, err error) {
+		return flag.Load(), nil
+	})
+}
+
+// UntilDrained returns a Task that blocks until the channel has at most watermark values buffered, returning nil.
+// The length is checked immediately and then every poll interval, ex. to pause a producer until a consumer catches up.
+// NOTE: The length is only a snapshot, so the channel may fill up again before the next send.
+func UntilDrained[T any](ch chan T, watermark int, poll time.Duration) (t Task) {
+	return Until(poll, func(ctx context.Context) (ok bool, err error) {
+		return len(ch) <= watermark, nil
+	})
+}
+
+// WatchValue returns a Task that polls the value every interval, calling onChange with the old and new values when it differs.
+// The first value is only recorded, so onChange isn't called until it changes, ex. to reload when an environment variable or config service changes.
+// It returns the first error from onChange, otherwise it loops until the context is done.
+func WatchValue[T comparable](get func() T, interval time.Duration, onChange func(ctx context.Context, old T, new T) (err error)) (t Task) {
+	return func(ctx context.Context) (err error) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := get()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+
+			v := get()
+			if v == last {
+				continue
+			}
+
+			old := last
+			last = v
+
+			err = onChange(ctx, old, v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
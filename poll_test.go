@@ -0,0 +1,385 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that Until polls until the condition is true.
+func TestUntil(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	cond := func(ctx context.Context) (ok bool, err error) {
+		count += 1
+		return count == 3, nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Until(time.Millisecond, cond))
+	require.NoError(err)
+	require.Equal(3, count)
+}
+
+// Test that the first poll happens immediately.
+func TestUntilImmediate(t *testing.T) {
+	require := require.New(t)
+
+	cond := func(ctx context.Context) (ok bool, err error) {
+		return true, nil
+	}
+
+	start := time.Now()
+
+	err := invoker.Run(context.Background(), invoker.Until(time.Hour, cond))
+	require.NoError(err)
+	require.True(time.Since(start) < time.Minute)
+}
+
+// Test that an error from the condition is returned.
+func TestUntilError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	cond := func(ctx context.Context) (ok bool, err error) {
+		return false, errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.Until(time.Millisecond, cond))
+	require.Equal(errSample, err)
+}
+
+// Test that Until returns on cancel.
+func TestUntilCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cond := func(ctx context.Context) (ok bool, err error) {
+		cancel()
+		return false, nil
+	}
+
+	err := invoker.Run(ctx, invoker.Until(time.Hour, cond))
+	require.Equal(context.Canceled, err)
+}
+
+// Test that UntilBelow returns once the sample crosses the threshold.
+func TestUntilBelow(t *testing.T) {
+	require := require.New(t)
+
+	// The load drops by one on each sample.
+	load := 5.0
+	sample := func() float64 {
+		load -= 1
+		return load
+	}
+
+	err := invoker.Run(context.Background(), invoker.UntilBelow(2, sample, time.Millisecond))
+	require.NoError(err)
+	require.Equal(1.0, load)
+}
+
+// Test that UntilBelow returns on cancel while under pressure.
+func TestUntilBelowCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sample := func() float64 {
+		cancel()
+		return 10
+	}
+
+	err := invoker.Run(ctx, invoker.UntilBelow(2, sample, time.Hour))
+	require.Equal(context.Canceled, err)
+}
+
+// Test that UntilFlag returns promptly once the flag is set.
+func TestUntilFlag(t *testing.T) {
+	require := require.New(t)
+
+	var flag atomic.Bool
+	time.AfterFunc(10*time.Millisecond, func() { flag.Store(true) })
+
+	start := time.Now()
+
+	err := invoker.Run(context.Background(), invoker.UntilFlag(&flag, time.Millisecond))
+	require.NoError(err)
+	require.True(flag.Load())
+	require.True(time.Since(start) < time.Second)
+}
+
+// Test that UntilFlag returns the context error when cancelled.
+func TestUntilFlagCancel(t *testing.T) {
+	require := require.New(t)
+
+	var flag atomic.Bool
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, invoker.UntilFlag(&flag, time.Millisecond))
+	require.Equal(context.Canceled, err)
+}
+
+// Test that UntilDrained returns once the channel drains to the watermark.
+func TestUntilDrained(t *testing.T) {
+	require := require.New(t)
+
+	ch := make(chan int, 10)
+	for i := 0; i < 10; i += 1 {
+		ch <- i
+	}
+
+	remaining := int64(-1)
+
+	consume := func(ctx context.Context) (err error) {
+		for i := 0; i < 10; i += 1 {
+			err = invoker.Sleep(time.Millisecond)(ctx)
+			if err != nil {
+				return err
+			}
+
+			<-ch
+		}
+
+		return nil
+	}
+
+	wait := func(ctx context.Context) (err error) {
+		err = invoker.UntilDrained(ch, 3, time.Millisecond)(ctx)
+		atomic.StoreInt64(&remaining, int64(len(ch)))
+		return err
+	}
+
+	err := invoker.Run(context.Background(), consume, wait)
+	require.NoError(err)
+
+	// The consumer may have taken another value since, but never more than the watermark remained.
+	n := atomic.LoadInt64(&remaining)
+	require.True(n >= 0 && n <= 3, "%d remaining", n)
+}
+
+// Test that UntilDrained returns the context error when cancelled.
+func TestUntilDrainedCancel(t *testing.T) {
+	require := require.New(t)
+
+	ch := make(chan int, 1)
+	ch <- 1
+
+	err := invoker.Run(context.Background(), invoker.UntilDrained(ch, 0, time.Millisecond), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that UntilTimed reports how long it waited for the condition.
+func TestUntilTimed(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	cond := func(ctx context.Context) (ok bool, err error) {
+		count += 1
+		return count == 3, nil
+	}
+
+	task, elapsed := invoker.UntilTimed(cond, 5*time.Millisecond, time.Second)
+	require.Equal(time.Duration(0), elapsed())
+
+	err := invoker.Run(context.Background(), task)
+	require.NoError(err)
+	require.Equal(3, count)
+
+	// Two intervals between the three checks.
+	require.True(elapsed() >= 10*time.Millisecond, "elapsed was %v", elapsed())
+	require.True(elapsed() < time.Second, "elapsed was %v", elapsed())
+}
+
+// Test that UntilTimed returns ErrProbeFailed after the timeout.
+func TestUntilTimedTimeout(t *testing.T) {
+	require := require.New(t)
+
+	cond := func(ctx context.Context) (ok bool, err error) {
+		return false, nil
+	}
+
+	task, elapsed := invoker.UntilTimed(cond, time.Millisecond, 10*time.Millisecond)
+
+	err := invoker.Run(context.Background(), task)
+	require.Equal(invoker.ErrProbeFailed, err)
+	require.True(errors.Is(err, context.DeadlineExceeded))
+	require.True(elapsed() >= 10*time.Millisecond, "elapsed was %v", elapsed())
+
+	// A deadline from the caller is returned as is.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	task, _ = invoker.UntilTimed(cond, time.Millisecond, time.Second)
+
+	err = invoker.Run(ctx, task)
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that UntilBackoff doubles the wait between checks up to the limit.
+func TestUntilBackoff(t *testing.T) {
+	require := require.New(t)
+
+	checks := 0
+	cond := func(ctx context.Context) (ok bool, err error) {
+		checks += 1
+		return checks == 6, nil
+	}
+
+	// Record the delays instead of waiting.
+	var delays []time.Duration
+	timer := func(d time.Duration) invoker.Task {
+		delays = append(delays, d)
+		return invoker.Noop
+	}
+
+	err := invoker.Run(context.Background(), invoker.UntilBackoff(cond, 2*time.Second, 8*time.Second, invoker.WithBackoffTimer(timer)))
+	require.NoError(err)
+	require.Equal(6, checks)
+	require.Equal([]time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second, 8 * time.Second}, delays)
+}
+
+// Test that a non-positive base is raised so it doesn't spin.
+func TestUntilBackoffZero(t *testing.T) {
+	require := require.New(t)
+
+	checks := 0
+	cond := func(ctx context.Context) (ok bool, err error) {
+		checks += 1
+		return checks == 3, nil
+	}
+
+	var delays []time.Duration
+	timer := func(d time.Duration) invoker.Task {
+		delays = append(delays, d)
+		return invoker.Noop
+	}
+
+	err := invoker.Run(context.Background(), invoker.UntilBackoff(cond, 0, 0, invoker.WithBackoffTimer(timer)))
+	require.NoError(err)
+	require.Equal([]time.Duration{time.Millisecond, time.Millisecond}, delays)
+}
+
+// Test that an error from the condition is returned.
+func TestUntilBackoffError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	cond := func(ctx context.Context) (ok bool, err error) {
+		return false, errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.UntilBackoff(cond, time.Millisecond, time.Second))
+	require.Equal(errSample, err)
+}
+
+// Test that UntilBackoff returns the context error when cancelled.
+func TestUntilBackoffCancel(t *testing.T) {
+	require := require.New(t)
+
+	cond := func(ctx context.Context) (ok bool, err error) {
+		return false, nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.UntilBackoff(cond, time.Hour, time.Hour), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that UntilGauge returns once the gauge crosses the threshold.
+func TestUntilGauge(t *testing.T) {
+	require := require.New(t)
+
+	var depth atomic.Int64
+	depth.Store(100)
+
+	get := func() float64 {
+		// Drain a little on each poll.
+		return float64(depth.Add(-10))
+	}
+
+	below := func(v float64) bool {
+		return v <= 50
+	}
+
+	err := invoker.Run(context.Background(), invoker.UntilGauge(get, below, time.Millisecond))
+	require.NoError(err)
+	require.Equal(int64(50), depth.Load())
+}
+
+// Test that UntilGauge returns the context error when cancelled.
+func TestUntilGaugeCancel(t *testing.T) {
+	require := require.New(t)
+
+	get := func() float64 {
+		return 1
+	}
+
+	never := func(v float64) bool {
+		return v > 1
+	}
+
+	err := invoker.Run(context.Background(), invoker.UntilGauge(get, never, time.Millisecond), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that WatchValue calls onChange with the old and new values each time the value changes.
+func TestWatchValue(t *testing.T) {
+	require := require.New(t)
+
+	// The value changes on some polls, and stays the same on others.
+	values := []string{"a", "a", "b", "b", "b", "c"}
+
+	polls := 0
+
+	get := func() string {
+		v := values[min(polls, len(values)-1)]
+		polls += 1
+
+		return v
+	}
+
+	var changes [][2]string
+	onChange := func(ctx context.Context, old string, new string) (err error) {
+		changes = append(changes, [2]string{old, new})
+
+		if new == "c" {
+			return invoker.ErrStopRequested
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.WatchValue(get, time.Millisecond, onChange))
+	require.Equal(invoker.ErrStopRequested, err)
+	require.Equal([][2]string{{"a", "b"}, {"b", "c"}}, changes)
+}
+
+// Test that WatchValue returns the context error without calling onChange for the initial value.
+func TestWatchValueCancel(t *testing.T) {
+	require := require.New(t)
+
+	get := func() int {
+		return 1
+	}
+
+	called := false
+	onChange := func(ctx context.Context, old int, new int) (err error) {
+		called = true
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.WatchValue(get, time.Millisecond, onChange), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.False(called)
+}
@@ -0,0 +1,32 @@
+package invoker
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+)
+
+// LabelIndex is the pprof label set to the task's index when WithPprofLabels is used.
+const LabelIndex = "invoker.index"
+
+// WithPprofLabels tags each task's goroutine with pprof labels, so goroutine profiles show which task is which.
+// The LabelIndex label is set to the order the task was added, starting at zero.
+func WithPprofLabels() Option {
+	return func(ts *Tasks) {
+		ts.pprofLabels = true
+	}
+}
+
+// call runs the task, applying pprof labels if configured.
+func (ts *Tasks) call(ctx context.Context, j job) (err error) {
+	if !ts.pprofLabels {
+		return j.task(ctx)
+	}
+
+	labels := pprof.Labels(LabelIndex, strconv.Itoa(j.index))
+	pprof.Do(ctx, labels, func(ctx context.Context) {
+		err = j.task(ctx)
+	})
+
+	return err
+}
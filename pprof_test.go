@@ -0,0 +1,57 @@
+package invoker_test
+
+import (
+	"context"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that each task sees its index as a pprof label.
+func TestPprofLabels(t *testing.T) {
+	require := require.New(t)
+
+	var mutex sync.Mutex
+	var labels []string
+
+	f := func(ctx context.Context) (err error) {
+		value, _ := pprof.Label(ctx, invoker.LabelIndex)
+
+		mutex.Lock()
+		labels = append(labels, value)
+		mutex.Unlock()
+
+		return nil
+	}
+
+	tasks := invoker.New(f, f, f).Apply(invoker.WithPprofLabels())
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+
+	sort.Strings(labels)
+	require.Equal([]string{"0", "1", "2"}, labels)
+}
+
+// Test that no labels are set by default.
+func TestPprofLabelsDisabled(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			count += 1
+			return true
+		})
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), f)
+	require.NoError(err)
+	require.Equal(0, count)
+}
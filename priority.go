@@ -0,0 +1,54 @@
+package invoker
+
+import "context"
+
+// AddPriority adds tasks that are started before any queued tasks with a lower priority.
+// This only matters when tasks are queued, ex. WithLimit or Pause, otherwise every task starts immediately.
+// Tasks with the same priority are started in the order they were added; Add uses a priority of zero.
+// Returns a handle that can cancel the tasks, like Add.
+func (ts *Tasks) AddPriority(priority int, tasks ...Task) (h *TaskHandle) {
+	h = new(TaskHandle)
+
+	h.err = ts.lockRoom(context.Background(), len(tasks))
+	if h.err != nil {
+		return h
+	}
+
+	defer ts.mutex.Unlock()
+
+	for _, t := range tasks {
+		ts.addPriority(h.wrap(t), 1, priority)
+	}
+
+	return h
+}
+
+// queue is a heap of jobs ordered by priority, then by the order they were added.
+type queue []job
+
+func (q queue) Len() int {
+	return len(q)
+}
+
+func (q queue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+
+	return q[i].index < q[j].index
+}
+
+func (q queue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *queue) Push(x interface{}) {
+	*q = append(*q, x.(job))
+}
+
+func (q *queue) Pop() interface{} {
+	old := *q
+	j := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return j
+}
@@ -0,0 +1,60 @@
+package invoker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// record returns a task that appends the name to the order.
+func record(mutex *sync.Mutex, order *[]string, name string) invoker.Task {
+	return func(ctx context.Context) (err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		*order = append(*order, name)
+		return nil
+	}
+}
+
+// Test that queued tasks start in priority order, then the order they were added.
+func TestAddPriority(t *testing.T) {
+	require := require.New(t)
+
+	var mutex sync.Mutex
+	var order []string
+
+	tasks := invoker.New().Apply(invoker.WithLimit(1))
+	tasks.Add(record(&mutex, &order, "low1"), record(&mutex, &order, "low2"))
+	tasks.AddPriority(10, record(&mutex, &order, "high1"), record(&mutex, &order, "high2"))
+	tasks.AddPriority(5, record(&mutex, &order, "mid"))
+	tasks.AddPriority(-1, record(&mutex, &order, "bulk"))
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal([]string{"high1", "high2", "mid", "low1", "low2", "bulk"}, order)
+}
+
+// Test that a task added with a higher priority during Run jumps the queue.
+func TestAddPriorityRunning(t *testing.T) {
+	require := require.New(t)
+
+	var mutex sync.Mutex
+	var order []string
+
+	tasks := invoker.New().Apply(invoker.WithLimit(1))
+
+	// Occupy the only slot while queueing the rest.
+	tasks.Add(func(ctx context.Context) (err error) {
+		tasks.Add(record(&mutex, &order, "bulk1"), record(&mutex, &order, "bulk2"))
+		tasks.AddPriority(1, record(&mutex, &order, "urgent"))
+		return nil
+	})
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal([]string{"urgent", "bulk1", "bulk2"}, order)
+}
@@ -0,0 +1,88 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ErrUnsettled is returned by the Promise result function before it's resolved or rejected.
+var ErrUnsettled = fmt.Errorf("promise not settled")
+
+// Promise is a value that's provided later, ex. by a callback-based API.
+// Only the first call to Resolve or Reject has any effect.
+// The zero value is ready to use.
+type Promise[T any] struct {
+	once    sync.Once
+	settled chan struct{}
+
+	mutex sync.Mutex
+	done  bool
+	value T
+	err   error
+}
+
+// init creates the channel, closed once settled.
+func (p *Promise[T]) init() {
+	p.once.Do(func() {
+		p.settled = make(chan struct{})
+	})
+}
+
+// Resolve settles the promise with the value, unless it was already settled.
+func (p *Promise[T]) Resolve(value T) {
+	p.settle(value, nil)
+}
+
+// Reject settles the promise with the error, unless it was already settled.
+func (p *Promise[T]) Reject(err error) {
+	var zero T
+	p.settle(zero, err)
+}
+
+func (p *Promise[T]) settle(value T, err error) {
+	p.init()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.done {
+		return
+	}
+
+	p.done = true
+	p.value = value
+	p.err = err
+
+	close(p.settled)
+}
+
+// Await returns a Task that blocks until the promise is settled, returning nil if resolved or the error if rejected.
+// The context error is returned if the context is done first.
+// The result function returns the settled value and error, or ErrUnsettled if the promise hasn't been settled yet.
+func (p *Promise[T]) Await() (t Task, result func() (value T, err error)) {
+	p.init()
+
+	t = func(ctx context.Context) (err error) {
+		select {
+		case <-p.settled:
+			_, err = result()
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	result = func() (value T, err error) {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+
+		if !p.done {
+			return value, ErrUnsettled
+		}
+
+		return p.value, p.err
+	}
+
+	return t, result
+}
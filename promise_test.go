@@ -0,0 +1,73 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that awaiting a promise returns once it's resolved, ignoring any later settlement.
+func TestPromiseResolve(t *testing.T) {
+	require := require.New(t)
+
+	var p invoker.Promise[string]
+
+	task, result := p.Await()
+
+	_, err := result()
+	require.Equal(invoker.ErrUnsettled, err)
+
+	// Resolved later, like a callback.
+	go func() {
+		time.Sleep(time.Millisecond)
+		p.Resolve("hello")
+		p.Reject(fmt.Errorf("ignored"))
+		p.Resolve("ignored")
+	}()
+
+	err = invoker.Run(context.Background(), task)
+	require.NoError(err)
+
+	value, err := result()
+	require.NoError(err)
+	require.Equal("hello", value)
+}
+
+// Test that awaiting a rejected promise returns the error.
+func TestPromiseReject(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	var p invoker.Promise[int]
+	p.Reject(errSample)
+	p.Resolve(1)
+
+	task, result := p.Await()
+
+	err := invoker.Run(context.Background(), task)
+	require.Equal(errSample, err)
+
+	value, err := result()
+	require.Equal(errSample, err)
+	require.Equal(0, value)
+}
+
+// Test that awaiting a promise can be cancelled before it's settled.
+func TestPromiseCancel(t *testing.T) {
+	require := require.New(t)
+
+	var p invoker.Promise[int]
+
+	task, result := p.Await()
+
+	err := invoker.Run(context.Background(), task, invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+
+	_, err = result()
+	require.Equal(invoker.ErrUnsettled, err)
+}
@@ -0,0 +1,158 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrInvalidRate is returned by a KeyedLimiter task when the rate isn't positive and finite.
+var ErrInvalidRate = fmt.Errorf("invalid rate")
+
+// Limiter is implemented by *rate.Limiter from golang.org/x/time/rate.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RateLimited returns a Task that repeatedly runs the task, waiting for the limiter before each invocation.
+// It loops until the task returns an error or the context is done.
+func RateLimited(lim Limiter, fn Task) (t Task) {
+	return func(ctx context.Context) (err error) {
+		for {
+			err = lim.Wait(ctx)
+			if err != nil {
+				// rate.Limiter returns its own error if the deadline is too soon.
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				return err
+			}
+
+			err = fn(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// keyedSweep is the minimum number of keys before idle buckets are removed.
+const keyedSweep = 64
+
+// KeyedLimiter rate limits tasks independently per key, ex. per tenant, so one key can't starve the others.
+// Each key has a leaky bucket that admits tasks one at a time at the given rate, without bursts.
+// Buckets are removed once idle, so memory is bounded by the active keys rather than every key ever seen.
+// The zero value is ready to use.
+type KeyedLimiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+
+	// the number of buckets that triggers the next sweep
+	sweep int
+}
+
+// bucket is a token bucket with a capacity of one.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+}
+
+// Wrap returns a Task that waits until the key has a token before running the task, at most rate times per second.
+// The context error is returned if the context is done while waiting, and the token is given back.
+// The rate must be positive and finite, otherwise ErrInvalidRate is returned without running the task.
+func (kl *KeyedLimiter) Wrap(key string, rate float64, t Task) Task {
+	return func(ctx context.Context) (err error) {
+		if !(rate > 0) || math.IsInf(rate, 1) {
+			return ErrInvalidRate
+		}
+
+		b, delay := kl.reserve(key, rate)
+
+		if delay > 0 {
+			err = Timer(delay)(ctx)
+			if err != nil {
+				kl.cancel(b)
+				return err
+			}
+		}
+
+		return t(ctx)
+	}
+}
+
+// Len returns the number of keys currently tracked, including any idle keys not yet removed.
+func (kl *KeyedLimiter) Len() int {
+	kl.mutex.Lock()
+	defer kl.mutex.Unlock()
+
+	return len(kl.buckets)
+}
+
+// reserve takes a token from the key's bucket, returning how long to wait until it's available.
+func (kl *KeyedLimiter) reserve(key string, rate float64) (b *bucket, delay time.Duration) {
+	kl.mutex.Lock()
+	defer kl.mutex.Unlock()
+
+	now := time.Now()
+
+	if kl.buckets == nil {
+		kl.buckets = make(map[string]*bucket)
+	}
+
+	b, ok := kl.buckets[key]
+	if !ok {
+		kl.clean(now)
+
+		b = &bucket{tokens: 1, last: now, rate: rate}
+		kl.buckets[key] = b
+	}
+
+	b.refill(now)
+	b.rate = rate
+
+	// The tokens go negative while tasks are waiting, reserving their turn.
+	b.tokens -= 1
+
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / rate * float64(time.Second))
+	}
+
+	return b, delay
+}
+
+// cancel gives back a reserved token.
+func (kl *KeyedLimiter) cancel(b *bucket) {
+	kl.mutex.Lock()
+	defer kl.mutex.Unlock()
+
+	b.tokens += 1
+}
+
+// clean removes any full buckets once there are enough keys, as a full bucket is the same as a new one.
+// The threshold doubles with the remaining keys so the cost is amortized.
+// The mutex must be held.
+func (kl *KeyedLimiter) clean(now time.Time) {
+	if len(kl.buckets) < max(kl.sweep, keyedSweep) {
+		return
+	}
+
+	for key, b := range kl.buckets {
+		b.refill(now)
+
+		if b.tokens >= 1 {
+			delete(kl.buckets, key)
+		}
+	}
+
+	kl.sweep = 2 * len(kl.buckets)
+}
+
+// refill adds tokens for the time elapsed since the last refill, up to one.
+func (b *bucket) refill(now time.Time) {
+	b.tokens = min(1, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
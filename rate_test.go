@@ -0,0 +1,150 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// ticker is a simple Limiter that allows one event per interval.
+type ticker struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *ticker) Wait(ctx context.Context) (err error) {
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+
+	return invoker.Timer(delay)(ctx)
+}
+
+// Test that the invocation rate stays within the limiter's bound.
+func TestRateLimited(t *testing.T) {
+	require := require.New(t)
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	lim := &ticker{interval: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := invoker.Run(ctx, invoker.RateLimited(lim, f))
+	require.Equal(context.DeadlineExceeded, err)
+
+	// The first event is immediate, then one per interval.
+	n := atomic.LoadUint64(&count)
+	require.True(n >= 1 && n <= 11, "unexpected count: %d", n)
+}
+
+// Test that an error from the task stops the loop.
+func TestRateLimitedError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		if count == 3 {
+			return errSample
+		}
+
+		return nil
+	}
+
+	lim := &ticker{interval: time.Millisecond}
+
+	err := invoker.Run(context.Background(), invoker.RateLimited(lim, f))
+	require.Equal(errSample, err)
+	require.Equal(3, count)
+}
+
+// Test that each key is rate limited independently.
+func TestKeyedLimiter(t *testing.T) {
+	require := require.New(t)
+
+	var kl invoker.KeyedLimiter
+
+	start := time.Now()
+
+	var slow, fast int64
+	finish := func(last *int64) invoker.Task {
+		return func(ctx context.Context) (err error) {
+			atomic.StoreInt64(last, int64(time.Since(start)))
+			return nil
+		}
+	}
+
+	tasks := invoker.New()
+	for i := 0; i < 3; i += 1 {
+		tasks.Add(kl.Wrap("slow", 20, finish(&slow)))
+		tasks.Add(kl.Wrap("fast", 1000, finish(&fast)))
+	}
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+
+	// The slow key admits one task immediately and then one every 50ms.
+	require.True(time.Duration(atomic.LoadInt64(&slow)) >= 100*time.Millisecond)
+	require.True(time.Duration(atomic.LoadInt64(&fast)) < 50*time.Millisecond)
+	require.Equal(2, kl.Len())
+}
+
+// Test that a waiting task returns when cancelled.
+func TestKeyedLimiterCancel(t *testing.T) {
+	require := require.New(t)
+
+	var kl invoker.KeyedLimiter
+
+	// Use the only token.
+	err := invoker.Run(context.Background(), kl.Wrap("key", 0.001, invoker.Noop))
+	require.NoError(err)
+
+	err = invoker.Run(context.Background(), kl.Wrap("key", 0.001, invoker.Noop), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that a rate that isn't positive and finite is rejected without running the task.
+func TestKeyedLimiterInvalidRate(t *testing.T) {
+	require := require.New(t)
+
+	var kl invoker.KeyedLimiter
+
+	for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		err := invoker.Run(context.Background(), kl.Wrap("key", rate, invoker.Noop))
+		require.Equal(invoker.ErrInvalidRate, err)
+	}
+
+	require.Equal(0, kl.Len())
+}
+
+// Test that idle keys are removed.
+func TestKeyedLimiterClean(t *testing.T) {
+	require := require.New(t)
+
+	var kl invoker.KeyedLimiter
+
+	for i := 0; i < 1000; i += 1 {
+		err := invoker.Run(context.Background(), kl.Wrap(fmt.Sprintf("key%d", i), 1e6, invoker.Noop))
+		require.NoError(err)
+	}
+
+	require.True(kl.Len() < 100, "%d keys", kl.Len())
+}
@@ -0,0 +1,83 @@
+package invoker
+
+import (
+	"context"
+)
+
+// Reason is why a group of tasks finished, ex. for a supervisor to decide whether to restart it.
+type Reason int
+
+const (
+	// ReasonNone means the group has not finished yet.
+	ReasonNone Reason = iota
+
+	// ReasonSuccess means the group finished without an error.
+	ReasonSuccess
+
+	// ReasonError means a task returned an error, including a cancellation not caused by the caller.
+	ReasonError
+
+	// ReasonCancel means the group was cancelled externally: by the parent context, a signal, or Close.
+	ReasonCancel
+
+	// ReasonPanic means a task panicked, see WithPanicLimit and Recover.
+	ReasonPanic
+)
+
+// String returns the name of the reason.
+func (r Reason) String() string {
+	switch r {
+	case ReasonNone:
+		return "none"
+	case ReasonSuccess:
+		return "success"
+	case ReasonError:
+		return "error"
+	case ReasonCancel:
+		return "cancel"
+	case ReasonPanic:
+		return "panic"
+	default:
+		return "unknown"
+	}
+}
+
+// Reason returns why Run/Race/Repeat finished, or ReasonNone if it hasn't yet.
+// It's set before Done is closed, so it's safe to read after Wait.
+func (ts *Tasks) Reason() Reason {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	return ts.reason
+}
+
+// reasonOf classifies the error that finished the group.
+// The mutex must be held.
+func (ts *Tasks) reasonOf(err error) Reason {
+	switch {
+	case err == nil:
+		return ReasonSuccess
+	case isPanic(err):
+		return ReasonPanic
+	case isSignal(err):
+		return ReasonCancel
+	case isCancel(err) && ts.cancelled():
+		return ReasonCancel
+	default:
+		return ReasonError
+	}
+}
+
+// cancelled returns true if the group was cancelled externally, rather than by a task.
+// The mutex must be held.
+func (ts *Tasks) cancelled() bool {
+	if ts.parent != nil && ts.parent.Err() != nil {
+		return true
+	}
+
+	if ts.base != nil && ts.base.Err() != nil {
+		return true
+	}
+
+	return ts.ctx != nil && context.Cause(ts.ctx) == ErrClosed
+}
@@ -0,0 +1,106 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a successful run is reported as a success.
+func TestReasonSuccess(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New(invoker.Noop)
+	require.Equal(invoker.ReasonNone, tasks.Reason())
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(invoker.ReasonSuccess, tasks.Reason())
+}
+
+// Test that a task error is reported as an error, even though it cancels the other tasks.
+func TestReasonError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	f := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	tasks := invoker.New(f, invoker.Wait)
+
+	err := tasks.Run(context.Background())
+	require.Equal(errSample, err)
+	require.Equal(invoker.ReasonError, tasks.Reason())
+}
+
+// Test that cancelling the parent context is reported as a cancel.
+func TestReasonCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tasks := invoker.New(invoker.Wait)
+
+	err := tasks.Run(ctx)
+	require.Equal(context.Canceled, err)
+	require.Equal(invoker.ReasonCancel, tasks.Reason())
+}
+
+// Test that Close is reported as a cancel.
+func TestReasonClose(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+
+	closer := func(ctx context.Context) (err error) {
+		return tasks.Close(context.Background(), false)
+	}
+
+	tasks.Add(closer, invoker.Wait)
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.Canceled, err)
+	require.Equal(invoker.ReasonCancel, tasks.Reason())
+}
+
+// Test that a recovered panic is reported as a panic.
+func TestReasonPanic(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New(panicky, invoker.Wait).Apply(invoker.WithPanicLimit(1))
+
+	err := tasks.Run(context.Background())
+	require.IsType(invoker.ErrPanic{}, err)
+	require.Equal(invoker.ReasonPanic, tasks.Reason())
+}
+
+// Test that the reason is set once Wait returns.
+func TestReasonWait(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New(invoker.Timeout(time.Millisecond))
+
+	go func() {
+		_ = tasks.Run(context.Background())
+	}()
+
+	err := tasks.Wait()
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(invoker.ReasonError, tasks.Reason())
+}
+
+// Test the names of each reason.
+func TestReasonString(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal("none", invoker.ReasonNone.String())
+	require.Equal("cancel", invoker.ReasonCancel.String())
+	require.Equal("panic", invoker.ReasonPanic.String())
+}
@@ -0,0 +1,42 @@
+package invoker
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Reconnect returns a Task that calls connect in a loop, ex. for a message queue or websocket client.
+// The connect function should block while connected, returning once it's disconnected or fails to connect.
+// Before reconnecting, it waits a random delay of up to base, doubling up to limit after each attempt (full jitter).
+// The backoff is reset once a connection lasts longer than limit, so a long-lived connection reconnects quickly.
+// Errors from connect are not returned, so it should log them; the Task only returns the context error once the context is done.
+func Reconnect(connect func(ctx context.Context) (err error), base time.Duration, limit time.Duration) (t Task) {
+	return func(ctx context.Context) (err error) {
+		backoff := base
+
+		for {
+			start := time.Now()
+
+			_ = connect(ctx)
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if time.Since(start) > limit {
+				backoff = base
+			}
+
+			// Full jitter spreads out clients that were disconnected at the same time.
+			delay := time.Duration(rand.Int63n(int64(backoff) + 1))
+
+			err = Timer(delay)(ctx)
+			if err != nil {
+				return err
+			}
+
+			backoff = min(2*backoff, limit)
+		}
+	}
+}
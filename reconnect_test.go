@@ -0,0 +1,76 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that Reconnect backs off between failures and resets after a long-lived connection.
+func TestReconnect(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	base := 10 * time.Millisecond
+	limit := 40 * time.Millisecond
+
+	// Attempts 0-4 fail immediately, 5 stays connected past the limit, then 6-7 fail again.
+	var starts []time.Time
+	var ends []time.Time
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connect := func(ctx context.Context) (err error) {
+		starts = append(starts, time.Now())
+		defer func() {
+			ends = append(ends, time.Now())
+		}()
+
+		switch len(starts) {
+		case 6:
+			time.Sleep(limit + 10*time.Millisecond)
+		case 8:
+			cancel()
+			<-ctx.Done()
+
+			return ctx.Err()
+		}
+
+		return errSample
+	}
+
+	err := invoker.Run(ctx, invoker.Reconnect(connect, base, limit))
+	require.Equal(context.Canceled, err)
+	require.Len(starts, 8)
+
+	// The delay before each attempt is at most the backoff, which doubles up to the limit and then resets.
+	caps := []time.Duration{10, 20, 40, 40, 40, 10, 20}
+
+	for i, c := range caps {
+		delay := starts[i+1].Sub(ends[i])
+		require.True(delay <= c*time.Millisecond+10*time.Millisecond, "delay %d was %v", i, delay)
+	}
+}
+
+// Test that Reconnect returns when cancelled while waiting to reconnect.
+func TestReconnectCancel(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	connect := func(ctx context.Context) (err error) {
+		count += 1
+		return errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.Reconnect(connect, time.Hour, time.Hour), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(1, count)
+}
@@ -0,0 +1,45 @@
+package invoker
+
+import (
+	"context"
+	"time"
+)
+
+// Renew returns a Task that keeps a lease or token alive by calling renew before each interval expires.
+// The lease is assumed to expire an interval after the last successful renewal.
+// Renewal is attempted a quarter of the interval early, and a failure is retried until the lease expires.
+// The last error is returned if the lease could not be renewed in time, cancelling the group.
+func Renew(interval time.Duration, renew func(ctx context.Context) error) (t Task) {
+	early := interval / 4
+	retry := early / 4
+
+	return func(ctx context.Context) (err error) {
+		expires := time.Now().Add(interval)
+		next := expires.Add(-early)
+
+		for {
+			err = Sleep(time.Until(next))(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = renew(ctx)
+			if err == nil {
+				expires = time.Now().Add(interval)
+				next = expires.Add(-early)
+
+				continue
+			}
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			// Retry unless the lease expires first.
+			next = time.Now().Add(retry)
+			if !next.Before(expires) {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the group fails once renewal keeps failing.
+func TestRenew(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	renew := func(ctx context.Context) (err error) {
+		count += 1
+		if count >= 3 {
+			return errSample
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Renew(40*time.Millisecond, renew), invoker.Wait)
+	require.Equal(errSample, err)
+
+	// The failed renewal was retried a few times before expiring.
+	require.True(count > 3, count)
+}
+
+// Test that a transient failure is retried before the lease expires.
+func TestRenewRetry(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	renew := func(ctx context.Context) (err error) {
+		count += 1
+
+		switch count {
+		case 1:
+			return errSample
+		case 3:
+			cancel()
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(ctx, invoker.Renew(40*time.Millisecond, renew))
+	require.Equal(context.Canceled, err)
+	require.Equal(3, count)
+}
@@ -0,0 +1,170 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errIterated is used internally to stop Repeat once every task has reached WithMaxIterations.
+var errIterated = fmt.Errorf("max iterations")
+
+// ErrStopRepeat is returned by the function passed to RepeatState to stop cleanly, causing RepeatState to return nil.
+var ErrStopRepeat = fmt.Errorf("stop repeat")
+
+// RepeatState calls fn repeatedly, passing the state returned by the previous call, starting with initial.
+// This threads state between iterations without closures, ex. a pagination cursor.
+// It stops and returns nil when fn returns ErrStopRepeat, or returns any other error or the context error.
+func RepeatState[S any](ctx context.Context, initial S, fn func(ctx context.Context, state S) (S, error)) (err error) {
+	state := initial
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		state, err = fn(ctx, state)
+		if errors.Is(err, ErrStopRepeat) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// WithRestart causes Repeat to restart any task that returns nil, instead of waiting for the remaining tasks.
+// The schedule returns the delay before each restart, starting with iteration zero after the first return.
+// A schedule that returns zero will restart immediately.
+func WithRestart(schedule func(iteration int) time.Duration) Option {
+	return func(ts *Tasks) {
+		ts.restart = schedule
+	}
+}
+
+// WithMaxIterations causes Repeat to run each task at most n times, restarting it like WithRestart.
+// A task that errors still cancels the group, otherwise Repeat returns nil once every task has run n times.
+// Without WithRestart, tasks are restarted immediately.
+func WithMaxIterations(n int) Option {
+	return func(ts *Tasks) {
+		ts.maxIterations = n
+	}
+}
+
+// WithPanicLimit recovers any panics from tasks, converting them into ErrPanic.
+// When combined with WithRestart, a task that panics is restarted until the group has recovered n panics in total.
+// The last ErrPanic is then returned, cancelling the group.
+// An ErrPanic is returned by Run/Repeat even if another task errored first, so it's never lost to the cancellation it caused.
+func WithPanicLimit(n int) Option {
+	return func(ts *Tasks) {
+		ts.panicLimit = n
+	}
+}
+
+// WithOnRestart calls the function each time WithRestart restarts a task, ex. to alert on crash loops.
+// The error is nil unless the task panicked, and count is the number of times the task has been restarted.
+// The function is called from the task's goroutine and must be safe for concurrent use.
+func WithOnRestart(fn func(index int, err error, count int)) Option {
+	return func(ts *Tasks) {
+		ts.onRestart = fn
+	}
+}
+
+// Restarts returns the number of times each task has been restarted by WithRestart, keyed by index.
+// Tasks that have not been restarted are not included.
+func (ts *Tasks) Restarts() map[int]int {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	restarts := make(map[int]int, len(ts.restarts))
+	for index, count := range ts.restarts {
+		restarts[index] = count
+	}
+
+	return restarts
+}
+
+// restartTask returns a Task that runs the task until it errors, sleeping between iterations based on the schedule.
+func (ts *Tasks) restartTask(index int, t Task) Task {
+	schedule := ts.restart
+	limit := ts.maxIterations
+
+	return func(ctx context.Context) (err error) {
+		for i := 0; ; i += 1 {
+			err = t(ctx)
+			if err != nil && !ts.recovered(err) {
+				return err
+			}
+
+			if limit > 0 && i+1 >= limit {
+				return nil
+			}
+
+			ts.restarted(index, err)
+
+			var delay time.Duration
+			if schedule != nil {
+				delay = schedule(i)
+			}
+
+			if delay <= 0 {
+				// Still check for cancellation so we don't spin forever.
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				continue
+			}
+
+			err = Sleep(delay)(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// recovered returns true if the error is a panic that is below the panic limit.
+func (ts *Tasks) recovered(err error) bool {
+	var ep ErrPanic
+	if !errors.As(err, &ep) {
+		return false
+	}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.panics += 1
+
+	return ts.panics < ts.panicLimit
+}
+
+// restarted increments the restart count for the task and calls the callback.
+func (ts *Tasks) restarted(index int, err error) {
+	ts.mutex.Lock()
+
+	if ts.restarts == nil {
+		ts.restarts = make(map[int]int)
+	}
+
+	ts.restarts[index] += 1
+	count := ts.restarts[index]
+
+	ts.mutex.Unlock()
+
+	if ts.onRestart != nil {
+		ts.onRestart(index, err, count)
+	}
+}
+
+// cause returns the cause of the context, hiding the internal errIterated.
+func (ts *Tasks) cause(ctx context.Context) error {
+	cause := context.Cause(ctx)
+	if cause == errIterated {
+		return nil
+	}
+
+	return cause
+}
@@ -0,0 +1,220 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a task returning instantly is restarted with a bounded rate.
+func TestRepeatRestart(t *testing.T) {
+	require := require.New(t)
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	var iterations []int
+	schedule := func(iteration int) time.Duration {
+		iterations = append(iterations, iteration)
+		return 10 * time.Millisecond
+	}
+
+	tasks := invoker.New(f).Apply(invoker.WithRestart(schedule))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	err := tasks.Repeat(ctx)
+	require.Equal(context.DeadlineExceeded, err)
+
+	n := atomic.LoadUint64(&count)
+	require.True(n >= 2 && n <= 7, n)
+	require.Equal(0, iterations[0])
+	require.Equal(len(iterations)-1, iterations[len(iterations)-1])
+}
+
+// Test that a zero schedule restarts immediately.
+func TestRepeatRestartImmediate(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		if atomic.AddUint64(&count, 1) == 100 {
+			cancel()
+		}
+
+		return nil
+	}
+
+	schedule := func(iteration int) time.Duration {
+		return 0
+	}
+
+	tasks := invoker.New(f).Apply(invoker.WithRestart(schedule))
+
+	err := tasks.Repeat(ctx)
+	require.Equal(context.Canceled, err)
+	require.Equal(uint64(100), atomic.LoadUint64(&count))
+}
+
+// Test that an always panicking task is restarted until the panic limit.
+func TestRepeatPanicLimit(t *testing.T) {
+	require := require.New(t)
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		panic("hello")
+	}
+
+	schedule := func(iteration int) time.Duration {
+		return 0
+	}
+
+	tasks := invoker.New(f).Apply(invoker.WithRestart(schedule), invoker.WithPanicLimit(3))
+
+	err := tasks.Repeat(context.Background())
+	require.IsType(invoker.ErrPanic{}, err)
+	require.Equal(uint64(3), atomic.LoadUint64(&count))
+}
+
+// Test that restarts are counted per task for a flapping task.
+func TestRepeatRestarts(t *testing.T) {
+	require := require.New(t)
+
+	flapping := func(ctx context.Context) (err error) {
+		panic("hello")
+	}
+
+	schedule := func(iteration int) time.Duration {
+		return 0
+	}
+
+	var counts []int
+	onRestart := func(index int, err error, count int) {
+		// Only the flapping task is restarted.
+		if index == 1 && err != nil {
+			counts = append(counts, count)
+		}
+	}
+
+	tasks := invoker.New(invoker.Wait, flapping).Apply(
+		invoker.WithRestart(schedule),
+		invoker.WithPanicLimit(4),
+		invoker.WithOnRestart(onRestart),
+	)
+
+	err := tasks.Repeat(context.Background())
+	require.IsType(invoker.ErrPanic{}, err)
+	require.Equal([]int{1, 2, 3}, counts)
+	require.Equal(map[int]int{1: 3}, tasks.Restarts())
+}
+
+// Test that Repeat returns nil once every task has run the maximum number of times.
+func TestRepeatMaxIterations(t *testing.T) {
+	require := require.New(t)
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	tasks := invoker.New(f, f).Apply(invoker.WithMaxIterations(5))
+
+	err := tasks.Repeat(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(10), atomic.LoadUint64(&count))
+}
+
+// Test that an error stops Repeat before the maximum.
+func TestRepeatMaxIterationsError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		if count == 3 {
+			return errSample
+		}
+
+		return nil
+	}
+
+	tasks := invoker.New(f).Apply(invoker.WithMaxIterations(5))
+
+	err := tasks.Repeat(context.Background())
+	require.Equal(errSample, err)
+	require.Equal(3, count)
+}
+
+// Test that RepeatState threads a cursor through each page until told to stop.
+func TestRepeatState(t *testing.T) {
+	require := require.New(t)
+
+	pages := [][]string{{"a", "b"}, {"c"}, {"d", "e"}}
+
+	var items []string
+	paginate := func(ctx context.Context, cursor int) (next int, err error) {
+		items = append(items, pages[cursor]...)
+
+		if cursor+1 == len(pages) {
+			return cursor, invoker.ErrStopRepeat
+		}
+
+		return cursor + 1, nil
+	}
+
+	err := invoker.RepeatState(context.Background(), 0, paginate)
+	require.NoError(err)
+	require.Equal([]string{"a", "b", "c", "d", "e"}, items)
+}
+
+// Test that RepeatState returns the first error.
+func TestRepeatStateError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	fn := func(ctx context.Context, count int) (next int, err error) {
+		if count == 3 {
+			return count, errSample
+		}
+
+		return count + 1, nil
+	}
+
+	err := invoker.RepeatState(context.Background(), 0, fn)
+	require.Equal(errSample, err)
+}
+
+// Test that RepeatState stops when the context is done.
+func TestRepeatStateCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	fn := func(ctx context.Context, count int) (next int, err error) {
+		if count == 3 {
+			cancel()
+		}
+
+		return count + 1, nil
+	}
+
+	err := invoker.RepeatState(ctx, 0, fn)
+	require.Equal(context.Canceled, err)
+}
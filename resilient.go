@@ -0,0 +1,105 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ResilientOption configures Resilient.
+type ResilientOption func(r *resilient)
+
+type resilient struct {
+	retries   int
+	backoff   func(retry int) time.Duration
+	threshold int
+	cooldown  time.Duration
+	timeout   time.Duration
+}
+
+// WithRetries retries a failed attempt up to n times, so the task runs at most n+1 times.
+func WithRetries(n int) ResilientOption {
+	return func(r *resilient) {
+		r.retries = n
+	}
+}
+
+// WithBackoff waits the returned delay before each retry, starting with retry zero.
+// Without this option, retries are immediate.
+func WithBackoff(backoff func(retry int) time.Duration) ResilientOption {
+	return func(r *resilient) {
+		r.backoff = backoff
+	}
+}
+
+// WithBreaker wraps each attempt in a CircuitBreaker with the given threshold and cooldown.
+// The breaker is shared by every invocation of the Task.
+func WithBreaker(threshold int, cooldown time.Duration) ResilientOption {
+	return func(r *resilient) {
+		r.threshold = threshold
+		r.cooldown = cooldown
+	}
+}
+
+// WithAttemptTimeout cancels each attempt after the duration, returning ErrTimeout.
+func WithAttemptTimeout(d time.Duration) ResilientOption {
+	return func(r *resilient) {
+		r.timeout = d
+	}
+}
+
+// Resilient returns a Task that combines retries, circuit breaking, and a per-attempt timeout.
+// The policies are applied from the outside in: each retry goes through the breaker, which then runs the attempt with a timeout.
+// Thus an attempt that times out counts as a failure for the breaker, and each retry can trip it.
+// Once the circuit is open, ErrCircuitOpen is returned immediately rather than retried.
+// The context error is returned if the context is done while waiting to retry.
+func Resilient(t Task, opts ...ResilientOption) Task {
+	var r resilient
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	attempt := t
+
+	if r.timeout > 0 {
+		attempt = attemptTimeout(attempt, r.timeout)
+	}
+
+	if r.threshold > 0 {
+		attempt = CircuitBreaker(attempt, r.threshold, r.cooldown)
+	}
+
+	return func(ctx context.Context) (err error) {
+		for retry := 0; ; retry += 1 {
+			err = attempt(ctx)
+			if err == nil || errors.Is(err, ErrCircuitOpen) || retry >= r.retries {
+				return err
+			}
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if r.backoff != nil {
+				if sleep := Timer(r.backoff(retry))(ctx); sleep != nil {
+					return sleep
+				}
+			}
+		}
+	}
+}
+
+// attemptTimeout runs the task with a timeout, returning ErrTimeout if it fired.
+func attemptTimeout(t Task, timeout time.Duration) Task {
+	return func(ctx context.Context) (err error) {
+		inner, cancel := context.WithTimeoutCause(ctx, timeout, ErrTimeout)
+		defer cancel()
+
+		err = t(inner)
+		if err != nil && ctx.Err() == nil && context.Cause(inner) == ErrTimeout {
+			return ErrTimeout
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,141 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a failed attempt is retried until it succeeds.
+func TestResilientRetries(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		if count < 3 {
+			return errSample
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Resilient(f, invoker.WithRetries(5)))
+	require.NoError(err)
+	require.Equal(3, count)
+}
+
+// Test that the last error is returned once the retries are exhausted.
+func TestResilientRetriesExhausted(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		return errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.Resilient(f, invoker.WithRetries(2)))
+	require.Equal(errSample, err)
+	require.Equal(3, count)
+}
+
+// Test that the backoff is called with each retry and waited.
+func TestResilientBackoff(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	f := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	var retries []int
+	backoff := func(retry int) time.Duration {
+		retries = append(retries, retry)
+		return 5 * time.Millisecond
+	}
+
+	start := time.Now()
+
+	err := invoker.Run(context.Background(), invoker.Resilient(f, invoker.WithRetries(3), invoker.WithBackoff(backoff)))
+	require.Equal(errSample, err)
+	require.Equal([]int{0, 1, 2}, retries)
+	require.True(time.Since(start) >= 15*time.Millisecond)
+}
+
+// Test that a slow attempt is cancelled with ErrTimeout and retried.
+func TestResilientAttemptTimeout(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		if count == 1 {
+			return invoker.Wait(ctx)
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Resilient(f, invoker.WithRetries(1), invoker.WithAttemptTimeout(time.Millisecond)))
+	require.NoError(err)
+	require.Equal(2, count)
+
+	err = invoker.Run(context.Background(), invoker.Resilient(invoker.Wait, invoker.WithAttemptTimeout(time.Millisecond)))
+	require.Equal(invoker.ErrTimeout, err)
+}
+
+// Test that attempts that time out trip the breaker, which then stops retrying.
+func TestResilientBreaker(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		return invoker.Wait(ctx)
+	}
+
+	task := invoker.Resilient(f,
+		invoker.WithRetries(10),
+		invoker.WithBreaker(2, time.Hour),
+		invoker.WithAttemptTimeout(time.Millisecond),
+	)
+
+	err := invoker.Run(context.Background(), task)
+	require.Equal(invoker.ErrCircuitOpen, err)
+	require.Equal(2, count)
+
+	// The breaker is shared, so the next run fails immediately.
+	err = invoker.Run(context.Background(), task)
+	require.Equal(invoker.ErrCircuitOpen, err)
+	require.Equal(2, count)
+}
+
+// Test that cancelling during the backoff returns the context error.
+func TestResilientCancel(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	f := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	backoff := func(retry int) time.Duration {
+		return time.Hour
+	}
+
+	task := invoker.Resilient(f, invoker.WithRetries(1), invoker.WithBackoff(backoff))
+
+	err := invoker.Run(context.Background(), task, invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
@@ -0,0 +1,59 @@
+package invoker
+
+import "context"
+
+// Result is a function that will execute until finished or the context is done, returning a value.
+type Result[T any] func(ctx context.Context) (v T, err error)
+
+// Collect will execute the given functions, returning their values in the same order.
+// The first error is returned and any remaining functions are canceled.
+func Collect[T any](ctx context.Context, fns ...Result[T]) (vs []T, err error) {
+	vs = make([]T, len(fns))
+	tasks := make([]Task, len(fns))
+
+	for i, fn := range fns {
+		i, fn := i, fn
+
+		tasks[i] = func(ctx context.Context) (err error) {
+			vs[i], err = fn(ctx)
+			return err
+		}
+	}
+
+	err = Run(ctx, tasks...)
+	if err != nil {
+		return nil, err
+	}
+
+	return vs, nil
+}
+
+// Stream will execute the given functions, sending each value to the channel as they finish.
+// The first error is returned and any remaining functions are canceled.
+// Sends will block until the value is received or the context is done.
+// The channel is closed once all functions have returned.
+func Stream[T any](ctx context.Context, out chan<- T, fns ...Result[T]) (err error) {
+	defer close(out)
+
+	tasks := make([]Task, len(fns))
+
+	for i, fn := range fns {
+		fn := fn
+
+		tasks[i] = func(ctx context.Context) (err error) {
+			v, err := fn(ctx)
+			if err != nil {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- v:
+				return nil
+			}
+		}
+	}
+
+	return Run(ctx, tasks...)
+}
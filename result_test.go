@@ -0,0 +1,66 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+func value(v int) invoker.Result[int] {
+	return func(ctx context.Context) (int, error) {
+		return v, nil
+	}
+}
+
+// Test that values are returned in order.
+func TestCollect(t *testing.T) {
+	require := require.New(t)
+
+	vs, err := invoker.Collect(context.Background(), value(1), value(2), value(3))
+	require.NoError(err)
+	require.Equal([]int{1, 2, 3}, vs)
+}
+
+// Test that every value is streamed and the channel is closed.
+func TestStream(t *testing.T) {
+	require := require.New(t)
+
+	out := make(chan int) // unbuffered to apply backpressure
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- invoker.Stream(context.Background(), out, value(1), value(2), value(3))
+	}()
+
+	var vs []int
+	for v := range out {
+		vs = append(vs, v)
+	}
+
+	sort.Ints(vs)
+
+	require.NoError(<-errs)
+	require.Equal([]int{1, 2, 3}, vs)
+}
+
+// Test that an error cancels a blocked send and still closes the channel.
+func TestStreamError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+	fail := func(ctx context.Context) (int, error) {
+		return 0, errSample
+	}
+
+	out := make(chan int) // never read until the stream is done
+
+	err := invoker.Stream(context.Background(), out, value(1), fail)
+	require.Equal(errSample, err)
+
+	_, ok := <-out
+	require.False(ok)
+}
@@ -0,0 +1,107 @@
+package invoker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RetryOption configures Retry.
+type RetryOption func(r *retry)
+
+type retry struct {
+	budget *RetryBudget
+}
+
+// WithRetryBudget consults the budget before each retry, returning the last error once it's exhausted.
+// The same budget can be shared by many tasks to avoid a retry storm when a shared dependency is down.
+func WithRetryBudget(b *RetryBudget) RetryOption {
+	return func(r *retry) {
+		r.budget = b
+	}
+}
+
+// Retry returns a Task that runs the task up to the given number of attempts until it returns nil.
+// The delay is waited between attempts, and the last error is returned if every attempt fails.
+// The context error is returned instead if the context is done while waiting.
+// The task is always run at least once, even if attempts is zero or negative.
+func Retry(t Task, attempts int, delay time.Duration, opts ...RetryOption) Task {
+	var r retry
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	attempts = max(attempts, 1)
+
+	return func(ctx context.Context) (err error) {
+		for i := 0; i < attempts; i += 1 {
+			if i > 0 {
+				if r.budget != nil && !r.budget.Allow() {
+					return err
+				}
+
+				if sleep := Timer(delay)(ctx); sleep != nil {
+					return sleep
+				}
+			}
+
+			err = t(ctx)
+			if err == nil {
+				return nil
+			}
+		}
+
+		return err
+	}
+}
+
+// RetryBudget is a token bucket that limits the total number of retries across tasks.
+// It's safe for concurrent use.
+type RetryBudget struct {
+	mutex    sync.Mutex
+	tokens   int
+	capacity int
+	refill   time.Duration
+	last     time.Time
+}
+
+// NewRetryBudget returns a budget that allows up to capacity retries.
+// A token is added back every refill interval, up to the capacity.
+// A refill of zero means the budget is never replenished.
+func NewRetryBudget(capacity int, refill time.Duration) *RetryBudget {
+	return &RetryBudget{
+		tokens:   capacity,
+		capacity: capacity,
+		refill:   refill,
+		last:     time.Now(),
+	}
+}
+
+// Allow consumes a token and returns true, or returns false if the budget is exhausted.
+func (b *RetryBudget) Allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.refill > 0 {
+		now := time.Now()
+		added := int(now.Sub(b.last) / b.refill)
+
+		if added > 0 {
+			b.tokens = min(b.tokens+added, b.capacity)
+			b.last = b.last.Add(time.Duration(added) * b.refill)
+		}
+
+		if b.tokens == b.capacity {
+			// Don't accumulate time while full.
+			b.last = now
+		}
+	}
+
+	if b.tokens <= 0 {
+		return false
+	}
+
+	b.tokens -= 1
+
+	return true
+}
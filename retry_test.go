@@ -0,0 +1,124 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a task is retried until it succeeds.
+func TestRetry(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		if count < 3 {
+			return errSample
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Retry(f, 5, time.Millisecond))
+	require.NoError(err)
+	require.Equal(3, count)
+}
+
+// Test that the last error is returned once the attempts are used up.
+func TestRetryAttempts(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		return errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.Retry(f, 3, 0))
+	require.Equal(errSample, err)
+	require.Equal(3, count)
+}
+
+// Test that the task is still run once with no attempts.
+func TestRetryZero(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		return errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.Retry(f, 0, 0))
+	require.Equal(errSample, err)
+	require.Equal(1, count)
+}
+
+// Test that the context error is returned while waiting between attempts.
+func TestRetryCancel(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	f := func(ctx context.Context) (err error) {
+		cancel()
+		return errSample
+	}
+
+	err := invoker.Run(ctx, invoker.Retry(f, 3, time.Hour))
+	require.Equal(context.Canceled, err)
+}
+
+// Test that a shared budget caps the total number of attempts.
+func TestRetryBudget(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		return errSample
+	}
+
+	budget := invoker.NewRetryBudget(4, 0)
+	opt := invoker.WithRetryBudget(budget)
+
+	// Run each task separately so one failure doesn't cancel the others.
+	for i := 0; i < 5; i += 1 {
+		err := invoker.Retry(f, 10, 0, opt)(context.Background())
+		require.Equal(errSample, err)
+	}
+
+	// One initial attempt per task, plus the shared retries.
+	require.Equal(5+4, count)
+	require.False(budget.Allow())
+}
+
+// Test that the budget is refilled over time.
+func TestRetryBudgetRefill(t *testing.T) {
+	require := require.New(t)
+
+	budget := invoker.NewRetryBudget(1, 10*time.Millisecond)
+
+	require.True(budget.Allow())
+	require.False(budget.Allow())
+
+	time.Sleep(20 * time.Millisecond)
+
+	require.True(budget.Allow())
+	require.False(budget.Allow())
+}
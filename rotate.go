@@ -0,0 +1,84 @@
+package invoker
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// RotateOption configures RotateLoop.
+type RotateOption func(r *rotation)
+
+type rotation struct {
+	onErr   func(err error)
+	signals []os.Signal
+}
+
+// WithRotateError passes any error from rotate to the handler and continues, instead of returning it.
+func WithRotateError(onErr func(err error)) RotateOption {
+	return func(r *rotation) {
+		r.onErr = onErr
+	}
+}
+
+// WithRotateSignal also rotates immediately when one of the signals is received, ex. SIGHUP from logrotate.
+func WithRotateSignal(signals ...os.Signal) RotateOption {
+	return func(r *rotation) {
+		r.signals = append(r.signals, signals...)
+	}
+}
+
+// RotateLoop returns a Task that calls rotate every interval, ex. to reopen a log file after it was rotated.
+// An interval of zero disables the periodic rotation, for example to only rotate on a signal.
+// An error from rotate is returned unless WithRotateError is provided, otherwise it loops until the context is done.
+func RotateLoop(interval time.Duration, rotate func(ctx context.Context) (err error), opts ...RotateOption) (t Task) {
+	var r rotation
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return func(ctx context.Context) (err error) {
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			tick = ticker.C
+		}
+
+		// A nil channel never receives, so there's no signal without the option.
+		var sig chan os.Signal
+		if len(r.signals) > 0 {
+			sig = make(chan os.Signal, 1)
+
+			signal.Notify(sig, r.signals...)
+			defer signal.Stop(sig)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-tick:
+			case <-sig:
+			}
+
+			// Don't rotate again if both were ready.
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			err = rotate(ctx)
+			if err == nil {
+				continue
+			}
+
+			if r.onErr == nil {
+				return err
+			}
+
+			r.onErr(err)
+		}
+	}
+}
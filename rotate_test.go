@@ -0,0 +1,99 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that RotateLoop rotates every interval, continuing on errors with a handler.
+func TestRotateLoop(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := uint64(0)
+	rotate := func(ctx context.Context) (err error) {
+		if atomic.AddUint64(&count, 1)%2 == 1 {
+			return errSample
+		}
+
+		return nil
+	}
+
+	failed := uint64(0)
+	onErr := func(err error) {
+		atomic.AddUint64(&failed, 1)
+	}
+
+	task := invoker.RotateLoop(5*time.Millisecond, rotate, invoker.WithRotateError(onErr))
+
+	err := invoker.Run(context.Background(), task, invoker.Timeout(28*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+
+	// Nothing happens immediately, then five ticks.
+	require.True(atomic.LoadUint64(&count) >= 2, "rotated %d times", count)
+	require.True(atomic.LoadUint64(&count) <= 5, "rotated %d times", count)
+	require.Equal((atomic.LoadUint64(&count)+1)/2, atomic.LoadUint64(&failed))
+}
+
+// Test that RotateLoop returns an error from rotate without a handler.
+func TestRotateLoopError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	rotate := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.RotateLoop(time.Millisecond, rotate))
+	require.Equal(errSample, err)
+}
+
+// Test that RotateLoop rotates immediately on a signal.
+func TestRotateLoopSignal(t *testing.T) {
+	require := require.New(t)
+
+	ignoreSignal(syscall.SIGHUP)
+
+	rotated := make(chan struct{}, 1)
+	rotate := func(ctx context.Context) (err error) {
+		select {
+		case rotated <- struct{}{}:
+		default:
+		}
+
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Only rotate on the signal.
+	errs := make(chan error, 1)
+	go func() {
+		errs <- invoker.Run(ctx, invoker.RotateLoop(0, rotate, invoker.WithRotateSignal(syscall.SIGHUP)))
+	}()
+
+	// Keep signaling until it rotates, since the task may not be listening yet.
+	for done := false; !done; {
+		require.NoError(syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+		select {
+		case <-rotated:
+			done = true
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	require.Equal(context.Canceled, <-errs)
+}
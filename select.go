@@ -0,0 +1,53 @@
+package invoker
+
+import (
+	"context"
+	"reflect"
+)
+
+// Select returns a Task that blocks until any of the channels receives or is closed, returning nil.
+// With no channels, it blocks until the context is done like Wait.
+func Select(chans ...<-chan struct{}) (t Task) {
+	return func(ctx context.Context) (err error) {
+		cases := make([]reflect.SelectCase, 0, 1+len(chans))
+		cases = append(cases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(ctx.Done()),
+		})
+
+		for _, ch := range chans {
+			cases = append(cases, reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(ch),
+			})
+		}
+
+		chosen, _, _ := reflect.Select(cases)
+		if chosen == 0 {
+			return ctx.Err()
+		}
+
+		return nil
+	}
+}
+
+// FromErrorChan returns a Task that returns the first error received on the channel.
+// Nil is returned if the channel is closed, while any nil values sent on the channel are skipped.
+func FromErrorChan(ch <-chan error) (t Task) {
+	return func(ctx context.Context) (err error) {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case err, ok := <-ch:
+				if !ok {
+					return nil
+				}
+
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that Select returns when any channel is closed, regardless of order.
+func TestSelect(t *testing.T) {
+	require := require.New(t)
+
+	for i := 0; i < 3; i += 1 {
+		chans := []chan struct{}{
+			make(chan struct{}),
+			make(chan struct{}),
+			make(chan struct{}),
+		}
+
+		close(chans[i])
+
+		err := invoker.Run(context.Background(), invoker.Select(chans[0], chans[1], chans[2]))
+		require.NoError(err)
+	}
+}
+
+// Test that Select returns when a channel receives a value.
+func TestSelectSend(t *testing.T) {
+	require := require.New(t)
+
+	a := make(chan struct{})
+	b := make(chan struct{}, 1)
+	b <- struct{}{}
+
+	err := invoker.Run(context.Background(), invoker.Select(a, b))
+	require.NoError(err)
+	require.Len(b, 0)
+}
+
+// Test that Select with no channels waits for the context.
+func TestSelectEmpty(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, invoker.Select())
+	require.Equal(context.Canceled, err)
+}
+
+// Test that Select returns on cancel.
+func TestSelectCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a := make(chan struct{})
+
+	err := invoker.Run(ctx, invoker.Select(a))
+	require.Equal(context.Canceled, err)
+}
+
+// Test that the first error received is returned.
+func TestFromErrorChan(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	ch := make(chan error, 2)
+	ch <- nil
+	ch <- errSample
+
+	err := invoker.Run(context.Background(), invoker.FromErrorChan(ch))
+	require.Equal(errSample, err)
+}
+
+// Test that nil is returned when the channel is closed.
+func TestFromErrorChanClosed(t *testing.T) {
+	require := require.New(t)
+
+	ch := make(chan error)
+	close(ch)
+
+	err := invoker.Run(context.Background(), invoker.FromErrorChan(ch))
+	require.NoError(err)
+}
+
+// Test that FromErrorChan returns on cancel.
+func TestFromErrorChanCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, invoker.FromErrorChan(make(chan error)))
+	require.Equal(context.Canceled, err)
+}
@@ -0,0 +1,200 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SequenceOnCancel returns a Task that blocks until the context is done, then runs each step in order.
+// This allows teardown to happen in a defined order instead of racing, ex. stop accepting, then drain, then close.
+// Every step is run even if a previous step fails, and any errors are joined.
+// NOTE: The steps are given a context that is not cancelled, so each step should bound its own duration.
+func SequenceOnCancel(steps ...Task) (t Task) {
+	return func(ctx context.Context) (err error) {
+		<-ctx.Done()
+
+		cleanup := context.WithoutCancel(ctx)
+
+		var errs []error
+		for _, step := range steps {
+			err = step(cleanup)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+
+		return ctx.Err()
+	}
+}
+
+// OnCancelTask returns a Task that blocks until the context is done, then runs fn with the cancellation cause and returns its error.
+// This propagates the cancellation to an external system, ex. sending a close frame with the reason.
+// Unlike AfterFunc, fn is given the cause and its error is part of the result, and it can be ordered with Phases.
+// The fn is given a context that is not cancelled but times out after the timeout, so a stuck peer can't block shutdown.
+func OnCancelTask(timeout time.Duration, fn func(ctx context.Context, cause error) error) (t Task) {
+	return func(ctx context.Context) (err error) {
+		<-ctx.Done()
+
+		fresh, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+
+		return fn(fresh, context.Cause(ctx))
+	}
+}
+
+// FlushLoop returns a Task that calls flush every interval, and one final time when the context is done.
+// The final flush is given a context that is not cancelled but times out after the interval, and its error is returned.
+// This avoids losing data buffered since the last tick on shutdown.
+// An error from a periodic flush is returned immediately without a final flush.
+func FlushLoop(interval time.Duration, flush func(ctx context.Context) error) (t Task) {
+	return func(ctx context.Context) (err error) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				final, cancel := context.WithTimeout(context.WithoutCancel(ctx), interval)
+				defer cancel()
+
+				return flush(final)
+			case <-ticker.C:
+			}
+
+			err = flush(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// SnapshotLoop returns a Task that calls snapshot every interval, and one final time when the context is done.
+// This is like FlushLoop, ex. to persist an in-memory cache, but snapshots never overlap or run back to back.
+// A tick that's missed while a slow snapshot is running is skipped, rather than starting another as soon as it returns.
+// The final snapshot is given a context that is not cancelled but times out after the interval, and its error is returned.
+// An error from a periodic snapshot is returned immediately, unless it was caused by the cancellation.
+func SnapshotLoop(interval time.Duration, snapshot func(ctx context.Context) error) (t Task) {
+	return func(ctx context.Context) (err error) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				final, cancel := context.WithTimeout(context.WithoutCancel(ctx), interval)
+				defer cancel()
+
+				return snapshot(final)
+			case <-ticker.C:
+			}
+
+			err = snapshot(ctx)
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+
+			// Skip any tick that fired while the snapshot was running.
+			select {
+			case <-ticker.C:
+			default:
+			}
+		}
+	}
+}
+
+// CleanupBarrier collects cleanups from many tasks and runs them all concurrently once the group is cancelled.
+// Unlike SequenceOnCancel, the teardown is parallel and bounded by a single timeout.
+// The zero value is ready to use.
+type CleanupBarrier struct {
+	mutex    sync.Mutex
+	cleanups map[string]func(ctx context.Context) error
+}
+
+// Register adds a cleanup with a name used to report if it fails or times out.
+// Registering the same name again replaces the previous cleanup.
+func (cb *CleanupBarrier) Register(name string, cleanup func(ctx context.Context) error) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.cleanups == nil {
+		cb.cleanups = make(map[string]func(ctx context.Context) error)
+	}
+
+	cb.cleanups[name] = cleanup
+}
+
+// Wait returns a Task that blocks until the context is done, then runs every registered cleanup concurrently.
+// The cleanups are given a context that is not cancelled but times out after the timeout.
+// Any cleanups that failed or didn't return in time are joined into the error, prefixed with their name and sorted.
+// Otherwise the context error is returned, like SequenceOnCancel.
+// NOTE: Cleanups that didn't return in time are detached and will leak until they return.
+func (cb *CleanupBarrier) Wait(timeout time.Duration) (t Task) {
+	return func(ctx context.Context) (err error) {
+		<-ctx.Done()
+
+		cb.mutex.Lock()
+		cleanups := make(map[string]func(ctx context.Context) error, len(cb.cleanups))
+		for name, cleanup := range cb.cleanups {
+			cleanups[name] = cleanup
+		}
+		cb.mutex.Unlock()
+
+		cleanup, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+
+		type result struct {
+			name string
+			err  error
+		}
+
+		// Buffered so late cleanups don't block forever.
+		results := make(chan result, len(cleanups))
+
+		for name, fn := range cleanups {
+			name, fn := name, fn
+
+			go func() {
+				results <- result{name: name, err: fn(cleanup)}
+			}()
+		}
+
+		var errs []error
+		pending := cleanups
+
+		for len(pending) > 0 {
+			select {
+			case r := <-results:
+				delete(pending, r.name)
+
+				if r.err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", r.name, r.err))
+				}
+			case <-cleanup.Done():
+				for name := range pending {
+					errs = append(errs, fmt.Errorf("%s: %w", name, ErrTimeout))
+				}
+
+				pending = nil
+			}
+		}
+
+		if len(errs) > 0 {
+			sort.Slice(errs, func(i, j int) bool {
+				return errs[i].Error() < errs[j].Error()
+			})
+
+			return errors.Join(errs...)
+		}
+
+		return ctx.Err()
+	}
+}
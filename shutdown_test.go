@@ -0,0 +1,328 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the steps run in order once cancelled.
+func TestSequenceOnCancel(t *testing.T) {
+	require := require.New(t)
+
+	var order []int
+	step := func(i int) invoker.Task {
+		return func(ctx context.Context) (err error) {
+			// The step context should not be cancelled.
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			// Sleep a little so any racing would be noticed.
+			time.Sleep(time.Duration(3-i) * time.Millisecond)
+			order = append(order, i)
+
+			return nil
+		}
+	}
+
+	err := invoker.Run(context.Background(), invoker.SequenceOnCancel(step(0), step(1), step(2)), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal([]int{0, 1, 2}, order)
+}
+
+// Test that every step runs even when one fails.
+func TestSequenceOnCancelError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	count := 0
+	step := func(ctx context.Context) (err error) {
+		count += 1
+		return nil
+	}
+
+	fail := func(ctx context.Context) (err error) {
+		count += 1
+		return errSample
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.SequenceOnCancel(step, fail, step)(ctx)
+	require.True(errors.Is(err, errSample))
+	require.Equal(3, count)
+}
+
+// Test that a final flush happens after cancel, even between ticks.
+func TestFlushLoop(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	count := 0
+	var finalErr error
+	flush := func(ctx context.Context) (err error) {
+		count += 1
+		finalErr = ctx.Err()
+
+		return nil
+	}
+
+	err := invoker.Run(ctx, invoker.FlushLoop(time.Hour, flush))
+	require.NoError(err)
+	require.Equal(1, count)
+	require.NoError(finalErr)
+}
+
+// Test that the periodic flushes happen and the final flush error is returned.
+func TestFlushLoopError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	flush := func(ctx context.Context) (err error) {
+		count += 1
+		if count < 3 {
+			return nil
+		}
+
+		if count == 3 {
+			cancel()
+			return nil
+		}
+
+		return errSample
+	}
+
+	err := invoker.Run(ctx, invoker.FlushLoop(time.Millisecond, flush))
+	require.Equal(errSample, err)
+	require.Equal(4, count)
+}
+
+// Test that OnCancelTask runs the function with the cause once the group is cancelled.
+func TestOnCancelTask(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+	errClose := fmt.Errorf("close")
+
+	var cause, fresh error
+	var bounded bool
+	fn := func(ctx context.Context, c error) (err error) {
+		cause = c
+		fresh = ctx.Err()
+		_, bounded = ctx.Deadline()
+
+		return errClose
+	}
+
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	tasks := invoker.New(invoker.OnCancelTask(time.Second, fn), fail)
+
+	err := tasks.Run(context.Background())
+	require.Equal(errSample, err)
+	require.Equal(errSample, cause)
+	require.NoError(fresh)
+	require.True(bounded)
+
+	// The error from fn is returned if it's the first.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = invoker.OnCancelTask(time.Second, fn)(ctx)
+	require.Equal(errClose, err)
+	require.Equal(context.Canceled, cause)
+}
+
+// Test that OnCancelTask can be ordered after other tasks with Phases.
+func TestOnCancelTaskPhases(t *testing.T) {
+	require := require.New(t)
+
+	var e events
+
+	closed := func(ctx context.Context, cause error) (err error) {
+		e.add("close " + cause.Error())
+		return nil
+	}
+
+	tasks := invoker.New(invoker.Timeout(time.Millisecond))
+	tasks.Phases().
+		Add(1, invoker.OnCancelTask(time.Second, closed)).
+		Add(0, e.phased("drain", 5*time.Millisecond))
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal([]string{"stop drain", "done drain", "close context deadline exceeded"}, e.list)
+}
+
+// Test that SnapshotLoop snapshots periodically and once more after cancel.
+func TestSnapshotLoop(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	var finalErr error
+	snapshot := func(ctx context.Context) (err error) {
+		count += 1
+		finalErr = ctx.Err()
+
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 22*time.Millisecond)
+	defer cancel()
+
+	err := invoker.SnapshotLoop(5*time.Millisecond, snapshot)(ctx)
+	require.NoError(err)
+
+	// Up to four periodic snapshots and the final one.
+	require.True(count >= 2 && count <= 5, "count was %d", count)
+	require.NoError(finalErr)
+}
+
+// Test that a tick missed during a slow snapshot is skipped instead of running back to back.
+func TestSnapshotLoopSlow(t *testing.T) {
+	require := require.New(t)
+
+	var starts, ends []time.Time
+	snapshot := func(ctx context.Context) (err error) {
+		starts = append(starts, time.Now())
+		defer func() {
+			ends = append(ends, time.Now())
+		}()
+
+		if ctx.Err() == nil {
+			time.Sleep(11 * time.Millisecond)
+		}
+
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Millisecond)
+	defer cancel()
+
+	err := invoker.SnapshotLoop(10*time.Millisecond, snapshot)(ctx)
+	require.NoError(err)
+	require.True(len(starts) >= 2, "snapshots: %d", len(starts))
+
+	// Each periodic snapshot waits for the next tick, ~9ms later, except the final one.
+	for i := 1; i < len(starts)-1; i += 1 {
+		gap := starts[i].Sub(ends[i-1])
+		require.True(gap >= 5*time.Millisecond, "gap %d was %v", i, gap)
+	}
+}
+
+// Test that a periodic snapshot error is returned, unless it was caused by the cancellation.
+func TestSnapshotLoopError(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	err := invoker.Run(context.Background(), invoker.SnapshotLoop(time.Millisecond, fail))
+	require.Equal(errSample, err)
+
+	// The periodic snapshot is cancelled, then the final one succeeds.
+	count := 0
+	slow := func(ctx context.Context) (err error) {
+		count += 1
+		if count > 1 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	err = invoker.SnapshotLoop(time.Millisecond, slow)(ctx)
+	require.NoError(err)
+	require.Equal(2, count)
+}
+
+// Test that the barrier runs every cleanup and reports those that failed or timed out.
+func TestCleanupBarrier(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	var cb invoker.CleanupBarrier
+
+	fast := uint64(0)
+	cb.Register("fast", func(ctx context.Context) (err error) {
+		atomic.AddUint64(&fast, 1)
+		return nil
+	})
+
+	cb.Register("fail", func(ctx context.Context) (err error) {
+		return errSample
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+
+	cb.Register("slow", func(ctx context.Context) (err error) {
+		// Ignores the context, so it's stuck.
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+
+	err := cb.Wait(20 * time.Millisecond)(ctx)
+	require.True(time.Since(start) < time.Second)
+	require.True(errors.Is(err, errSample))
+	require.True(errors.Is(err, invoker.ErrTimeout))
+	require.Equal("fail: hello\nslow: invoker timeout: context deadline exceeded", err.Error())
+	require.Equal(uint64(1), atomic.LoadUint64(&fast))
+}
+
+// Test that the barrier returns the context error when every cleanup succeeds.
+func TestCleanupBarrierSuccess(t *testing.T) {
+	require := require.New(t)
+
+	var cb invoker.CleanupBarrier
+
+	count := uint64(0)
+	for _, name := range []string{"a", "b", "c"} {
+		cb.Register(name, func(ctx context.Context) (err error) {
+			atomic.AddUint64(&count, 1)
+			return nil
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := cb.Wait(time.Second)(ctx)
+	require.Equal(context.Canceled, err)
+	require.Equal(uint64(3), atomic.LoadUint64(&count))
+}
@@ -6,9 +6,11 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 // Signal returns a Task that blocks until one of the given signals is triggered.
+// A signal that arrives at the same time as the context is cancelled still returns ErrSignal, as it's likely the reason.
 func Signal(signals ...os.Signal) (t Task) {
 	return func(ctx context.Context) (err error) {
 		c := make(chan os.Signal, 1)
@@ -16,15 +18,101 @@ func Signal(signals ...os.Signal) (t Task) {
 		signal.Notify(c, signals...)
 		defer signal.Stop(c)
 
+		select {
+		case <-ctx.Done():
+		case sig := <-c:
+			return ErrSignal{sig: sig}
+		}
+
+		// Prefer the signal if both are ready, ex. the context was cancelled by signal.NotifyContext.
+		select {
+		case sig := <-c:
+			return ErrSignal{sig: sig}
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// UntilSignalOrTimeout returns a Task that blocks until one of the given signals is triggered or the duration has elapsed.
+// An ErrSignal is returned for a signal, while ErrTimeout is returned for the duration.
+func UntilSignalOrTimeout(d time.Duration, signals ...os.Signal) (t Task) {
+	return func(ctx context.Context) (err error) {
+		c := make(chan os.Signal, 1)
+
+		signal.Notify(c, signals...)
+		defer signal.Stop(c)
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case sig := <-c:
 			return ErrSignal{sig: sig}
+		case <-timer.C:
+			return ErrTimeout
 		}
 	}
 }
 
+// CoalesceSignal returns a Task that runs the handler each time one of the given signals is triggered.
+// Any signals received while the handler is running are coalesced into a single follow-up run.
+func CoalesceSignal(handler Task, signals ...os.Signal) (t Task) {
+	return func(ctx context.Context) (err error) {
+		// The signal package drops signals when the channel is full.
+		// A buffer of one acts as a dirty flag: it's set on signal and cleared right before the handler runs.
+		// Any signal that arrives after the handler starts will trigger another run.
+		c := make(chan os.Signal, 1)
+
+		signal.Notify(c, signals...)
+		defer signal.Stop(c)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c:
+			}
+
+			err = handler(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// SignalChan returns a Task that forwards the given signals to the returned channel, without returning on a signal.
+// This allows signals like SIGUSR1 to drive application behavior while the group keeps running.
+// The Task only returns when the context is done, and the channel is not closed.
+func SignalChan(signals ...os.Signal) (t Task, ch <-chan os.Signal) {
+	out := make(chan os.Signal, 1)
+
+	t = func(ctx context.Context) (err error) {
+		c := make(chan os.Signal, 1)
+
+		signal.Notify(c, signals...)
+		defer signal.Stop(c)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case sig := <-c:
+				select {
+				case out <- sig:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+
+	return t, out
+}
+
 // Interrupt is a Task that blocks until a terminate signal.
 // Specifically: SIGTERM (kill default), SIGINT (ctrl+c), and SIGHUP (common kill signal)
 func Interrupt(ctx context.Context) (err error) {
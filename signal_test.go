@@ -0,0 +1,174 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// ignoreSignal makes sure the signal never uses the default behavior of terminating the process.
+// The channel is never stopped, otherwise a signal delivered late could still terminate the test.
+func ignoreSignal(sig os.Signal) {
+	signal.Notify(make(chan os.Signal, 1), sig)
+}
+
+// Test that signals received during a slow handler are coalesced into one run.
+func TestCoalesceSignal(t *testing.T) {
+	require := require.New(t)
+
+	ignoreSignal(syscall.SIGUSR1)
+
+	count := uint64(0)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := func(ctx context.Context) (err error) {
+		if atomic.AddUint64(&count, 1) == 1 {
+			close(started)
+		}
+
+		<-release
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- invoker.Run(ctx, invoker.CoalesceSignal(handler, syscall.SIGUSR1))
+	}()
+
+	// Keep signaling until the handler starts, since the task may not be listening yet.
+	for running := false; !running; {
+		require.NoError(syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+		select {
+		case <-started:
+			running = true
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// Burst while the handler is still running.
+	for i := 0; i < 5; i += 1 {
+		require.NoError(syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+
+	require.Equal(context.Canceled, <-errs)
+	require.Equal(uint64(2), atomic.LoadUint64(&count))
+}
+
+// Test that signals are forwarded to the channel while the task stays alive.
+func TestSignalChan(t *testing.T) {
+	require := require.New(t)
+
+	// SIGWINCH is ignored by default and not used by other tests.
+	task, signals := invoker.SignalChan(syscall.SIGWINCH)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- invoker.Run(ctx, task)
+	}()
+
+	// Keep signaling until it's received, since the task may not be listening yet.
+	for received := 0; received < 2; {
+		require.NoError(syscall.Kill(os.Getpid(), syscall.SIGWINCH))
+
+		select {
+		case sig := <-signals:
+			require.Equal(syscall.SIGWINCH, sig)
+			received += 1
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// Still running after the signals.
+	select {
+	case err := <-errs:
+		require.Fail("returned early", err)
+	default:
+	}
+
+	cancel()
+	require.Equal(context.Canceled, <-errs)
+}
+
+// Test that the timeout fires without a signal.
+func TestUntilSignalOrTimeoutTimeout(t *testing.T) {
+	require := require.New(t)
+
+	err := invoker.Run(context.Background(), invoker.UntilSignalOrTimeout(time.Millisecond, syscall.SIGUSR2))
+	require.Equal(invoker.ErrTimeout, err)
+}
+
+// Test that a signal fires before the timeout.
+func TestUntilSignalOrTimeoutSignal(t *testing.T) {
+	require := require.New(t)
+
+	done := make(chan struct{})
+	go signalUntil(syscall.SIGUSR2, done)
+
+	err := invoker.Run(context.Background(), invoker.UntilSignalOrTimeout(time.Minute, syscall.SIGUSR2))
+	close(done)
+
+	require.IsType(invoker.ErrSignal{}, err)
+	require.Equal(syscall.SIGUSR2, err.(invoker.ErrSignal).Signal())
+}
+
+// Test that cancellation is honored.
+func TestUntilSignalOrTimeoutCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, invoker.UntilSignalOrTimeout(time.Minute, syscall.SIGUSR2))
+	require.Equal(context.Canceled, err)
+}
+
+// Test that ErrSignal is returned instead of the cancellation it causes, even when they race.
+func TestInterruptPrecedence(t *testing.T) {
+	require := require.New(t)
+
+	server := func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	for i := 0; i < 50; i += 1 {
+		// The parent is also cancelled by the signal, like a typical main.
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGHUP)
+
+		done := make(chan struct{})
+		go func() {
+			// Give Interrupt a chance to start listening, otherwise only the parent would see the signal.
+			time.Sleep(10 * time.Millisecond)
+			signalUntil(syscall.SIGHUP, done)
+		}()
+
+		err := invoker.Run(ctx, server, invoker.Interrupt)
+		close(done)
+		stop()
+
+		var es invoker.ErrSignal
+		require.True(errors.As(err, &es), "iteration %d: %v", i, err)
+	}
+}
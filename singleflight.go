@@ -0,0 +1,103 @@
+package invoker
+
+import (
+	"context"
+	"sync"
+)
+
+// SingleFlight deduplicates concurrent executions of tasks with the same key, like golang.org/x/sync/singleflight.
+// The zero value is ready to use.
+type SingleFlight struct {
+	mutex sync.Mutex
+
+	// the in-flight executions by key
+	calls map[string]*flight
+}
+
+// flight is a single execution shared by every caller with the same key.
+type flight struct {
+	done    chan struct{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Do returns a Task that runs the task unless another with the same key is in-flight, sharing its result instead.
+// The shared execution keeps values from the first caller's context, and is cancelled only once every caller's context is done.
+// A caller whose context is done returns the context error without waiting.
+func (sf *SingleFlight) Do(key string, t Task) Task {
+	return func(ctx context.Context) (err error) {
+		f := sf.join(ctx, key, t)
+
+		select {
+		case <-f.done:
+			return f.err
+		case <-ctx.Done():
+			sf.leave(key, f)
+			return ctx.Err()
+		}
+	}
+}
+
+// join returns the in-flight execution for the key, starting one if needed.
+func (sf *SingleFlight) join(ctx context.Context, key string, t Task) *flight {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	f, ok := sf.calls[key]
+	if !ok {
+		// Detach from the caller so the execution outlives it if others are waiting.
+		ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+
+		if sf.calls == nil {
+			sf.calls = make(map[string]*flight)
+		}
+
+		sf.calls[key] = f
+
+		go sf.run(ctx, key, f, t)
+	}
+
+	f.waiters += 1
+
+	return f
+}
+
+// run executes the task and wakes every caller.
+func (sf *SingleFlight) run(ctx context.Context, key string, f *flight, t Task) {
+	err := t(ctx)
+	f.cancel()
+
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	f.err = err
+	sf.remove(key, f)
+
+	close(f.done)
+}
+
+// leave is called when a caller stops waiting, cancelling the execution if nobody else is.
+func (sf *SingleFlight) leave(key string, f *flight) {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	f.waiters -= 1
+
+	if f.waiters == 0 {
+		f.cancel()
+
+		// Any new callers should start a fresh execution.
+		sf.remove(key, f)
+	}
+}
+
+// remove deletes the execution unless it has already been replaced.
+// The mutex must be held.
+func (sf *SingleFlight) remove(key string, f *flight) {
+	if sf.calls[key] == f {
+		delete(sf.calls, key)
+	}
+}
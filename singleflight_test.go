@@ -0,0 +1,103 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that concurrent tasks with the same key run once and share the error.
+func TestSingleFlight(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	var sf invoker.SingleFlight
+	var joined invoker.Counter
+
+	count := uint64(0)
+	fill := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+
+		err = joined.WaitZero()(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Give the last caller a moment to join.
+		err = invoker.Sleep(10 * time.Millisecond)(ctx)
+		if err != nil {
+			return err
+		}
+
+		return errSample
+	}
+
+	shared := uint64(0)
+
+	tasks := make([]invoker.Task, 100)
+	for i := range tasks {
+		joined.Inc()
+
+		tasks[i] = func(ctx context.Context) (err error) {
+			joined.Dec()
+
+			err = sf.Do("key", fill)(ctx)
+			if err == errSample {
+				atomic.AddUint64(&shared, 1)
+			}
+
+			return err
+		}
+	}
+
+	err := invoker.RunAll(context.Background(), tasks...)
+	require.Error(err)
+	require.Equal(uint64(1), atomic.LoadUint64(&count))
+	require.Equal(uint64(100), atomic.LoadUint64(&shared))
+}
+
+// Test that different keys run independently.
+func TestSingleFlightKeys(t *testing.T) {
+	require := require.New(t)
+
+	var sf invoker.SingleFlight
+
+	count := uint64(0)
+	f := func(ctx context.Context) (err error) {
+		atomic.AddUint64(&count, 1)
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), sf.Do("a", f), sf.Do("b", f))
+	require.NoError(err)
+	require.Equal(uint64(2), atomic.LoadUint64(&count))
+}
+
+// Test that the execution is cancelled once every caller has given up.
+func TestSingleFlightCancel(t *testing.T) {
+	require := require.New(t)
+
+	var sf invoker.SingleFlight
+
+	cancelled := make(chan struct{})
+	f := func(ctx context.Context) (err error) {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}
+
+	err := invoker.Run(context.Background(), sf.Do("key", f), sf.Do("key", f), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		require.Fail("execution was not cancelled")
+	}
+}
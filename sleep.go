@@ -2,6 +2,7 @@ package invoker
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,3 +20,102 @@ func Sleep(d time.Duration) (t Task) {
 		}
 	}
 }
+
+// SleepRemaining returns a Task like Sleep, and a function that returns the remaining duration if the sleep was cut short.
+// The remaining duration is zero if the sleep finished or has not started.
+func SleepRemaining(d time.Duration) (t Task, remaining func() time.Duration) {
+	var left atomic.Int64
+
+	t = func(ctx context.Context) (err error) {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		deadline := time.Now().Add(d)
+
+		select {
+		case <-ctx.Done():
+			left.Store(int64(time.Until(deadline)))
+			return ctx.Err()
+		case <-timer.C:
+			left.Store(0)
+			return nil
+		}
+	}
+
+	remaining = func() time.Duration {
+		return time.Duration(left.Load())
+	}
+
+	return t, remaining
+}
+
+// Interval returns a Task that runs the task immediately and then every period, measured from start to start.
+// Unlike calling Sleep in a loop, the time spent in the task doesn't cause the period to drift.
+// If the task takes longer than the period, any missed ticks are skipped like time.Ticker.
+// It loops until the task returns an error or the context is done.
+func Interval(period time.Duration, fn Task) (t Task) {
+	return func(ctx context.Context) (err error) {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			err = fn(ctx)
+			if err != nil {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// Poll returns a Task that runs the task, then waits the delay after it returns before running it again.
+// Unlike Interval, the delay is measured from the end of one run to the start of the next.
+// It loops until the task returns an error or the context is done.
+func Poll(delay time.Duration, fn Task) (t Task) {
+	return func(ctx context.Context) (err error) {
+		for {
+			err = fn(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = Sleep(delay)(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// TickTolerant returns a Task that runs the task every interval, passing any error to onErr and continuing.
+// This is useful for best-effort periodic jobs, ex. flushing metrics, that shouldn't stop on a single failure.
+// It returns nil when the context is done.
+func TickTolerant(interval time.Duration, fn Task, onErr func(err error)) (t Task) {
+	return func(ctx context.Context) (err error) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+
+			// Don't run again if both were ready.
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			err = fn(ctx)
+			if err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}
+}
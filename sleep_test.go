@@ -0,0 +1,177 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the remaining duration is reported when cancelled.
+func TestSleepRemaining(t *testing.T) {
+	require := require.New(t)
+
+	sleep, remaining := invoker.SleepRemaining(time.Second)
+
+	err := invoker.Run(context.Background(), sleep, invoker.Timeout(100*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+
+	left := remaining()
+	require.True(left > 800*time.Millisecond, left)
+	require.True(left <= 900*time.Millisecond, left)
+}
+
+// Test that nothing remains when the sleep finishes.
+func TestSleepRemainingFinished(t *testing.T) {
+	require := require.New(t)
+
+	sleep, remaining := invoker.SleepRemaining(time.Millisecond)
+
+	err := invoker.Run(context.Background(), sleep)
+	require.NoError(err)
+	require.Equal(time.Duration(0), remaining())
+}
+
+// Test that the start-to-start spacing doesn't drift with the task's duration.
+func TestInterval(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	period := 20 * time.Millisecond
+
+	var starts []time.Time
+	f := func(ctx context.Context) (err error) {
+		starts = append(starts, time.Now())
+		if len(starts) == 6 {
+			return errSample
+		}
+
+		// Alternate between fast and slow work.
+		if len(starts)%2 == 0 {
+			time.Sleep(period * 3 / 4)
+		}
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Interval(period, f))
+	require.Equal(errSample, err)
+	require.Len(starts, 6)
+
+	for i := 1; i < len(starts); i += 1 {
+		gap := starts[i].Sub(starts[i-1])
+		require.True(gap >= period*3/4, "gap too short: %s", gap)
+	}
+
+	// Sleeping after the work would take at least 5 periods plus the slow work.
+	total := starts[5].Sub(starts[0])
+	require.True(total < 5*period+period, "drifted: %s", total)
+}
+
+// Test that Interval returns on cancel.
+func TestIntervalCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	f := func(ctx context.Context) (err error) {
+		cancel()
+		return nil
+	}
+
+	err := invoker.Run(ctx, invoker.Interval(time.Hour, f))
+	require.Equal(context.Canceled, err)
+}
+
+// Test that the delay is measured from when the task returns.
+func TestPoll(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	delay := 10 * time.Millisecond
+
+	var starts, ends []time.Time
+	f := func(ctx context.Context) (err error) {
+		starts = append(starts, time.Now())
+		defer func() {
+			ends = append(ends, time.Now())
+		}()
+
+		if len(starts) == 4 {
+			return errSample
+		}
+
+		// Work for longer than the delay.
+		time.Sleep(2 * delay)
+
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Poll(delay, f))
+	require.Equal(errSample, err)
+	require.Len(starts, 4)
+
+	for i := 1; i < len(starts); i += 1 {
+		gap := starts[i].Sub(ends[i-1])
+		require.True(gap >= delay, "gap too short: %s", gap)
+	}
+}
+
+// Test that Poll can be interrupted during the delay.
+func TestPollCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	count := 0
+	f := func(ctx context.Context) (err error) {
+		count += 1
+		cancel()
+
+		return nil
+	}
+
+	err := invoker.Run(ctx, invoker.Poll(time.Hour, f))
+	require.Equal(context.Canceled, err)
+	require.Equal(1, count)
+}
+
+// Test that errors are reported without stopping the ticker.
+func TestTickTolerant(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ticks := 0
+	f := func(ctx context.Context) (err error) {
+		ticks += 1
+		if ticks == 6 {
+			cancel()
+		}
+
+		// Fail every other tick.
+		if ticks%2 == 0 {
+			return errSample
+		}
+
+		return nil
+	}
+
+	var errs []error
+	onErr := func(err error) {
+		errs = append(errs, err)
+	}
+
+	err := invoker.Run(ctx, invoker.TickTolerant(time.Millisecond, f, onErr))
+	require.NoError(err)
+	require.Equal(6, ticks)
+	require.Equal([]error{errSample, errSample, errSample}, errs)
+}
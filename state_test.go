@@ -0,0 +1,106 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that tasks added after an empty run see a cancelled context.
+func TestAddAfterEmpty(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New()
+	require.NoError(tasks.Run(context.Background()))
+
+	errs := make(chan error, 1)
+	tasks.Add(func(ctx context.Context) (err error) {
+		errs <- invoker.Wait(ctx)
+		return nil
+	})
+
+	require.Equal(context.Canceled, <-errs)
+	_, ok := tasks.TryAdd(invoker.Noop)
+	require.False(ok)
+}
+
+// Test that concurrent lifecycle calls on a single group are consistent.
+func TestLifecycleStress(t *testing.T) {
+	require := require.New(t)
+
+	for i := 0; i < 50; i += 1 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+		tasks := invoker.New(invoker.Noop)
+
+		var wg sync.WaitGroup
+		results := make(chan error, 8)
+
+		calls := []func() error{
+			func() error { return tasks.Run(ctx) },
+			func() error { return tasks.Race(ctx) },
+			func() error { return tasks.Repeat(ctx) },
+		}
+
+		for j := 0; j < 8; j += 1 {
+			call := calls[j%len(calls)]
+
+			wg.Add(4)
+
+			go func() {
+				defer wg.Done()
+				results <- call()
+			}()
+
+			go func() {
+				defer wg.Done()
+				_ = tasks.RunShared(ctx)
+			}()
+
+			go func() {
+				defer wg.Done()
+				tasks.Add(invoker.Noop)
+				tasks.TryAdd(invoker.Noop)
+			}()
+
+			go func() {
+				defer wg.Done()
+				_ = tasks.Status()
+				_ = tasks.Err()
+				_ = tasks.Names()
+			}()
+		}
+
+		// Repeat never finishes on its own, so cancel once everything else has settled.
+		go func() {
+			time.Sleep(time.Millisecond)
+			cancel()
+		}()
+
+		wg.Wait()
+		close(results)
+		cancel()
+
+		// At most one call should have executed the group, the rest may have lost to RunShared.
+		executed := 0
+		for err := range results {
+			if !errors.Is(err, invoker.ErrRunning) && !errors.Is(err, invoker.ErrFinished) {
+				executed += 1
+			}
+		}
+
+		require.True(executed <= 1, "executed %d times", executed)
+		require.Equal(invoker.StatusDone, tasks.Status())
+
+		select {
+		case <-tasks.Done():
+		default:
+			require.Fail("not done")
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package invoker
+
+import "fmt"
+
+// ErrStrict is the panic value when WithStrictChecks detects an inconsistent state.
+var ErrStrict = fmt.Errorf("strict check failed")
+
+// WithStrictChecks panics with ErrStrict if the group's internal state becomes inconsistent.
+// This catches state machine regressions during development, ex. the result being written twice or a task reporting twice.
+// The checks are skipped by default so production isn't affected.
+// NOTE: The panic happens on whichever goroutine detected it, usually a task's, so it will crash the program.
+func WithStrictChecks() Option {
+	return func(ts *Tasks) {
+		ts.strict = true
+	}
+}
+
+// check panics with ErrStrict if strict checks are enabled and the condition is false.
+// The mutex must be held.
+func (ts *Tasks) check(ok bool, format string, args ...any) {
+	if ts.strict && !ok {
+		panic(fmt.Errorf("%w: %s", ErrStrict, fmt.Sprintf(format, args...)))
+	}
+}
@@ -0,0 +1,155 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// NOTE: These tests are internal so they can corrupt the state on purpose.
+
+// recovered runs the function and returns the error it panicked with, if any.
+func recovered(fn func()) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = p.(error)
+		}
+	}()
+
+	fn()
+
+	return nil
+}
+
+// Test that the strict checks don't fire for the legitimate edge orderings.
+func TestStrictOrderings(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	release := make(chan struct{})
+	stuck := func(ctx context.Context) (err error) {
+		<-release
+		return nil
+	}
+
+	fail := func(ctx context.Context) (err error) {
+		return errSample
+	}
+
+	// Tasks still running after the cancel timeout report after done.
+	tasks := New(stuck, fail).Apply(WithStrictChecks(), WithCancelTimeout(time.Millisecond))
+
+	err := tasks.Run(context.Background())
+	require.True(errors.As(err, new(ErrStuck)))
+
+	close(release)
+
+	// Tasks added after done are started and report after done.
+	tasks.Add(func(ctx context.Context) (err error) {
+		return ctx.Err()
+	})
+
+	// Closing a running group completes without the task reporting first.
+	tasks = New(Wait).Apply(WithStrictChecks())
+
+	go func() {
+		time.Sleep(time.Millisecond)
+		tasks.Close(context.Background(), false)
+	}()
+
+	err = tasks.Run(context.Background())
+	require.Error(err)
+
+	// Repeat stops itself once every iteration has finished.
+	count := 0
+	tasks = New(func(ctx context.Context) (err error) {
+		count += 1
+		return nil
+	}).Apply(WithStrictChecks(), WithMaxIterations(3))
+
+	err = tasks.Repeat(context.Background())
+	require.NoError(err)
+	require.Equal(3, count)
+
+	// Give the late reports time to run the checks, which would crash the test.
+	time.Sleep(10 * time.Millisecond)
+}
+
+// Test that the strict checks fire when the group completes twice.
+func TestStrictComplete(t *testing.T) {
+	require := require.New(t)
+
+	tasks := New(func(ctx context.Context) (err error) {
+		return nil
+	}).Apply(WithStrictChecks())
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+
+	tasks.mutex.Lock()
+	defer tasks.mutex.Unlock()
+
+	err = recovered(tasks.complete)
+	require.True(errors.Is(err, ErrStrict))
+}
+
+// Test that the strict checks fire when the result is written twice.
+func TestStrictResult(t *testing.T) {
+	require := require.New(t)
+
+	tasks := New().Apply(WithStrictChecks())
+	tasks.mode = modeRun
+	tasks.done = make(chan error, 1)
+	tasks.done <- nil
+
+	err := recovered(tasks.complete)
+	require.True(errors.Is(err, ErrStrict))
+}
+
+// Test that the strict checks fire when a task reports with nothing running.
+func TestStrictReport(t *testing.T) {
+	require := require.New(t)
+
+	tasks := New().Apply(WithStrictChecks())
+	tasks.mode = modeDone
+
+	err := recovered(func() {
+		tasks.report(0, nil)
+	})
+	require.True(errors.Is(err, ErrStrict))
+
+	// The checks are skipped by default.
+	tasks = New()
+	tasks.mode = modeDone
+
+	err = recovered(func() {
+		tasks.report(0, nil)
+	})
+	require.NoError(err)
+}
+
+// Test that the strict checks fire when the same task reports twice.
+func TestStrictTwice(t *testing.T) {
+	require := require.New(t)
+
+	tasks := New().Apply(WithStrictChecks())
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(nil)
+
+	tasks.mode = modeDone
+	tasks.ctx = ctx
+	tasks.cancel = cancel
+	tasks.running = 2
+
+	// The task isn't live, as if it had already reported.
+	err := recovered(func() {
+		tasks.run(ctx, job{index: 0, task: Wait})
+	})
+	require.True(errors.Is(err, ErrStrict))
+}
@@ -0,0 +1,81 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WithCancelTimeout limits how long to wait for tasks to return after the group is cancelled.
+// If tasks are still running after the timeout, ErrStuck is returned instead of blocking forever.
+// NOTE: The stuck tasks are detached and will leak until they return.
+func WithCancelTimeout(d time.Duration) Option {
+	return func(ts *Tasks) {
+		ts.cancelTimeout = d
+	}
+}
+
+// ErrStuck is returned when tasks did not return within the cancel timeout.
+type ErrStuck struct {
+	indexes []int
+}
+
+func (es ErrStuck) Error() string {
+	return fmt.Sprintf("tasks stuck after cancel: %v", es.indexes)
+}
+
+// Indexes returns the order the stuck tasks were added, starting at zero.
+func (es ErrStuck) Indexes() []int {
+	return es.indexes
+}
+
+// finish blocks until all tasks have returned, or the cancel timeout has elapsed.
+func (ts *Tasks) finish(ctx context.Context) (err error) {
+	if ts.cancelTimeout == 0 {
+		return <-ts.done
+	}
+
+	select {
+	case err = <-ts.done:
+		return err
+	case <-ctx.Done():
+	}
+
+	timer := time.NewTimer(ts.cancelTimeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-ts.done:
+		return err
+	case <-timer.C:
+	}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	// Check again now that we have the mutex, in case the last task just returned.
+	select {
+	case err = <-ts.done:
+		return err
+	default:
+	}
+
+	indexes := make([]int, 0, len(ts.live))
+	for index := range ts.live {
+		indexes = append(indexes, index)
+	}
+
+	sort.Ints(indexes)
+
+	// Any stuck tasks that eventually return will be ignored.
+	reason := ts.err
+	if reason == nil {
+		// The tasks must have been cancelled to get here.
+		reason = ts.ctx.Err()
+	}
+
+	ts.setDone(reason)
+
+	return ErrStuck{indexes: indexes}
+}
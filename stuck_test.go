@@ -0,0 +1,41 @@
+package invoker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a task ignoring cancellation is reported as stuck.
+func TestCancelTimeout(t *testing.T) {
+	require := require.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	stuck := func(ctx context.Context) (err error) {
+		<-release
+		return nil
+	}
+
+	tasks := invoker.New(invoker.Wait, stuck, invoker.Timeout(time.Millisecond))
+	tasks.Apply(invoker.WithCancelTimeout(10 * time.Millisecond))
+
+	err := tasks.Run(context.Background())
+	require.IsType(invoker.ErrStuck{}, err)
+	require.Equal([]int{1}, err.(invoker.ErrStuck).Indexes())
+}
+
+// Test that tasks returning before the cancel timeout behave normally.
+func TestCancelTimeoutFinished(t *testing.T) {
+	require := require.New(t)
+
+	tasks := invoker.New(invoker.Wait, invoker.Timeout(time.Millisecond))
+	tasks.Apply(invoker.WithCancelTimeout(time.Second))
+
+	err := tasks.Run(context.Background())
+	require.Equal(context.DeadlineExceeded, err)
+}
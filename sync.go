@@ -0,0 +1,198 @@
+package invoker
+
+import (
+	"context"
+	"sync"
+)
+
+// WaitGroup returns a Task that blocks until the WaitGroup counter is zero.
+// NOTE: WaitGroup.Wait is not cancelable, so a goroutine will keep waiting after the context is done.
+func WaitGroup(wg *sync.WaitGroup) (t Task) {
+	return func(ctx context.Context) (err error) {
+		done := make(chan struct{})
+
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// Counter tracks in-flight work, like a sync.WaitGroup that can be waited on with cancellation.
+// The zero value is ready to use.
+type Counter struct {
+	mutex sync.Mutex
+	count int
+
+	// closed when the count reaches zero, nil while zero
+	zero chan struct{}
+}
+
+// Inc increments the counter.
+func (c *Counter) Inc() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.count == 0 {
+		c.zero = make(chan struct{})
+	}
+
+	c.count += 1
+}
+
+// Dec decrements the counter, waking any waiters when it reaches zero.
+// Unlike sync.WaitGroup, decrementing past zero is ignored instead of panicking.
+func (c *Counter) Dec() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.count == 0 {
+		return
+	}
+
+	c.count -= 1
+
+	if c.count == 0 {
+		close(c.zero)
+		c.zero = nil
+	}
+}
+
+// Count returns the current value of the counter.
+func (c *Counter) Count() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.count
+}
+
+// WaitZero returns a Task that blocks until the counter is zero, returning nil.
+// This is useful to wait for in-flight requests to drain on shutdown.
+func (c *Counter) WaitZero() (t Task) {
+	return func(ctx context.Context) (err error) {
+		c.mutex.Lock()
+		zero := c.zero
+		c.mutex.Unlock()
+
+		if zero == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-zero:
+			return nil
+		}
+	}
+}
+
+// Gate is a mutex where Lock can be cancelled by the context.
+// The zero value is ready to use.
+type Gate struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+// init creates the channel, holding a value while locked.
+func (g *Gate) init() {
+	g.once.Do(func() {
+		g.ch = make(chan struct{}, 1)
+	})
+}
+
+// Lock blocks until the gate is acquired, returning nil, or the context error if it's done first.
+func (g *Gate) Lock(ctx context.Context) (err error) {
+	g.init()
+
+	select {
+	case g.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Unlock releases the gate, which must be locked.
+func (g *Gate) Unlock() {
+	g.init()
+
+	select {
+	case <-g.ch:
+	default:
+		panic("invoker: unlock of unlocked gate")
+	}
+}
+
+// Guarded returns a Task that runs the task while holding the gate, so tasks sharing the gate never run concurrently.
+// This allows mixing concurrent and mutually exclusive tasks in one group, ex. around a client that isn't thread-safe.
+func Guarded(g *Gate, t Task) Task {
+	return func(ctx context.Context) (err error) {
+		err = g.Lock(ctx)
+		if err != nil {
+			return err
+		}
+
+		defer g.Unlock()
+
+		return t(ctx)
+	}
+}
+
+// Latch is a countdown that opens once Ready has been called the expected number of times, ex. for dependencies at startup.
+// Unlike a barrier, it's one-directional: the dependencies don't wait for each other, only the work waiting on Open does.
+type Latch struct {
+	mutex     sync.Mutex
+	remaining int
+
+	// closed once remaining reaches zero
+	open chan struct{}
+}
+
+// NewLatch returns a Latch that opens after n calls to Ready, or immediately if n is zero.
+func NewLatch(n int) (l *Latch) {
+	l = &Latch{remaining: n, open: make(chan struct{})}
+
+	if n <= 0 {
+		close(l.open)
+	}
+
+	return l
+}
+
+// Ready marks one dependency as ready, opening the latch if it was the last.
+// Any extra calls are ignored.
+func (l *Latch) Ready() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.remaining <= 0 {
+		return
+	}
+
+	l.remaining -= 1
+
+	if l.remaining == 0 {
+		close(l.open)
+	}
+}
+
+// Open returns a Task that blocks until the latch is open, returning nil.
+// Work that depends on the latch can be chained after it with Then.
+func (l *Latch) Open() (t Task) {
+	return func(ctx context.Context) (err error) {
+		select {
+		case <-l.open:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
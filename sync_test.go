@@ -0,0 +1,205 @@
+package invoker_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the task returns once the WaitGroup is done.
+func TestWaitGroup(t *testing.T) {
+	require := require.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	done := func(ctx context.Context) (err error) {
+		wg.Done()
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.WaitGroup(&wg), done, done)
+	require.NoError(err)
+}
+
+// Test that the task returns on cancel even if the WaitGroup isn't done.
+func TestWaitGroupCancel(t *testing.T) {
+	require := require.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Done()
+
+	err := invoker.Run(context.Background(), invoker.WaitGroup(&wg), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
+
+// Test that WaitZero returns once concurrent work has drained.
+func TestCounter(t *testing.T) {
+	require := require.New(t)
+
+	var c invoker.Counter
+
+	// Increment before starting, so WaitZero doesn't return immediately.
+	for i := 0; i < 10; i += 1 {
+		c.Inc()
+	}
+
+	work := func(ctx context.Context) (err error) {
+		c.Inc()
+		c.Dec()
+		c.Dec()
+
+		return nil
+	}
+
+	tasks := invoker.New(c.WaitZero())
+	for i := 0; i < 10; i += 1 {
+		tasks.Add(work)
+	}
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(0, c.Count())
+}
+
+// Test that WaitZero returns immediately at zero, and going negative is ignored.
+func TestCounterZero(t *testing.T) {
+	require := require.New(t)
+
+	var c invoker.Counter
+	c.Dec()
+	require.Equal(0, c.Count())
+
+	err := invoker.Run(context.Background(), c.WaitZero())
+	require.NoError(err)
+}
+
+// Test that WaitZero returns on cancel while work is in flight.
+func TestCounterCancel(t *testing.T) {
+	require := require.New(t)
+
+	var c invoker.Counter
+	c.Inc()
+
+	err := invoker.Run(context.Background(), c.WaitZero(), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.Equal(1, c.Count())
+}
+
+// Test that guarded tasks sharing a gate never run concurrently.
+func TestGuarded(t *testing.T) {
+	require := require.New(t)
+
+	var gate invoker.Gate
+
+	active := int64(0)
+	peak := int64(0)
+
+	f := func(ctx context.Context) (err error) {
+		n := atomic.AddInt64(&active, 1)
+		defer atomic.AddInt64(&active, -1)
+
+		if n > atomic.LoadInt64(&peak) {
+			atomic.StoreInt64(&peak, n)
+		}
+
+		time.Sleep(time.Millisecond)
+		return nil
+	}
+
+	tasks := invoker.New()
+	for i := 0; i < 10; i += 1 {
+		tasks.Add(invoker.Guarded(&gate, f))
+	}
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+	require.Equal(int64(1), atomic.LoadInt64(&peak))
+}
+
+// Test that acquiring the gate can be cancelled.
+func TestGuardedCancel(t *testing.T) {
+	require := require.New(t)
+
+	var gate invoker.Gate
+	require.NoError(gate.Lock(context.Background()))
+	defer gate.Unlock()
+
+	ran := false
+	f := func(ctx context.Context) (err error) {
+		ran = true
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.Guarded(&gate, f), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.False(ran)
+}
+
+// Test that unlocking an unlocked gate panics, like sync.Mutex.
+func TestGateUnlock(t *testing.T) {
+	require := require.New(t)
+
+	var gate invoker.Gate
+	require.Panics(gate.Unlock)
+}
+
+// Test that work chained after the latch only starts once every dependency is ready.
+func TestLatch(t *testing.T) {
+	require := require.New(t)
+
+	latch := invoker.NewLatch(3)
+
+	ready := int64(0)
+	dependency := func(ctx context.Context) (err error) {
+		time.Sleep(time.Millisecond)
+		atomic.AddInt64(&ready, 1)
+		latch.Ready()
+
+		// Keep running after being ready, like a server.
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	seen := int64(-1)
+	work := func(ctx context.Context) (err error) {
+		atomic.StoreInt64(&seen, atomic.LoadInt64(&ready))
+		return invoker.ErrStopRequested
+	}
+
+	err := invoker.Run(context.Background(), dependency, dependency, dependency, latch.Open().Then(work))
+	require.Equal(invoker.ErrStopRequested, err)
+	require.Equal(int64(3), atomic.LoadInt64(&seen))
+}
+
+// Test that the latch stays open after extra calls, and opens immediately for zero.
+func TestLatchExtra(t *testing.T) {
+	require := require.New(t)
+
+	latch := invoker.NewLatch(1)
+	latch.Ready()
+	latch.Ready()
+
+	err := invoker.Run(context.Background(), latch.Open())
+	require.NoError(err)
+
+	err = invoker.Run(context.Background(), invoker.NewLatch(0).Open())
+	require.NoError(err)
+}
+
+// Test that waiting on the latch can be cancelled.
+func TestLatchCancel(t *testing.T) {
+	require := require.New(t)
+
+	latch := invoker.NewLatch(2)
+	latch.Ready()
+
+	err := invoker.Run(context.Background(), latch.Open(), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+}
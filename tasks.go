@@ -1,15 +1,31 @@
 package invoker
 
 import (
+	"container/heap"
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // ErrRunning is returned if two goroutine try to similtaniously call Run/Race.
 var ErrRunning = fmt.Errorf("already running")
 var ErrFinished = fmt.Errorf("finished execution")
 
+// mode is the state of the group, guarded by the mutex.
+//
+// The transitions are:
+//
+//	modeInit -> modeRun/modeRace/modeRepeat/modeAny/modeRaceSuccess: the first call to Run/Race/Repeat/Any/RaceSuccess.
+//	modeInit -> modeDone: the first call to Run/Race/Any/RaceSuccess with no tasks.
+//	modeRun/modeRace/modeRepeat/modeAny/modeRaceSuccess -> modeDone: the last task has returned, or WithCancelTimeout expired.
+//
+// Any other call to Run/Race/Repeat/Any/RaceSuccess returns ErrRunning or ErrFinished without changing the mode.
+// Tasks added in modeDone are started with a cancelled context and their results are ignored.
+//
+// NOTE: The values must match the exported Mode.
 type mode int
 
 const (
@@ -17,66 +33,432 @@ const (
 	modeRun
 	modeRace
 	modeRepeat
+	modeAny
+	modeRaceSuccess
 	modeDone
 )
 
+// Outcome describes the execution of a group of tasks.
+type Outcome struct {
+	// The number of tasks that returned nil.
+	Succeeded int
+
+	// The number of tasks that returned an error, including cancellation.
+	Failed int
+
+	// The time between starting the first task and the last task returning.
+	Duration time.Duration
+
+	// The reason the tasks were cancelled, or nil if they were not cancelled.
+	// This is the first error or the cause of the parent context being cancelled.
+	Cause error
+}
+
 // Tasks allows you to add additional tasks during a Run/Race.
 type Tasks struct {
 	mutex sync.Mutex
 
-	mode    mode
-	pending []Task
+	mode mode
+
+	// the queued tasks, with the next to start at the front
+	pending queue
+	next    int
+
+	// the indexes of tasks that have started but not returned
+	live map[int]bool
 
 	running int
+	weight  int64
+	limit   int64
+	paused  bool
 	first   bool
 	err     error
 
-	ctx    context.Context
-	cancel context.CancelFunc
-	done   chan error
+	succeeded int
+	failed    int
+
+	// errors from Race losers that were not cancelled
+	extra []error
+
+	// errors from Any tasks before one succeeded
+	failures []result
+
+	// results waiting for the raceLoser callback, see notifyLosers
+	losers []result
+
+	// serializes the raceLoser callbacks, which are called without the mutex
+	loserMutex sync.Mutex
+
+	// closed when a RaceSuccess task succeeds
+	won chan struct{}
+
+	vars           *expvar.Map
+	restart        func(iteration int) time.Duration
+	cancelTimeout  time.Duration
+	panicLimit     int
+	panics         int
+	restarts       map[int]int
+	maxIterations  int
+	iterated       bool
+	closed         bool
+	fullDump       bool
+	onRestart      func(index int, err error, count int)
+	cancelSuccess  bool
+	allowEmpty     bool
+	maxRunning     int
+	maxBlock       bool
+	cancelExternal bool
+	taskErrors     bool
+	timing         func(index int, d time.Duration, err error)
+	onFinish       func(index int, err error)
+	pprofLabels    bool
+	raceIgnore     func(err error) bool
+	raceLoser      func(index int, err error)
+	lowestError    bool
+	rootCause      bool
+	strict         bool
+
+	// the error from the lowest index, see WithLowestIndexError
+	lowest      error
+	lowestIndex int
+
+	// cleanup functions registered with Defer, run in reverse order
+	deferred []func()
+
+	// the shutdown phases, see Phases
+	phases *Phases
+
+	// the names of tasks added with AddNamed, by index
+	names map[int]string
+
+	// notified on each mode change, see Subscribe
+	subscribers []chan Mode
+
+	// Race results that matched raceIgnore, used if there's no other winner
+	ignored []result
+
+	// closed and replaced each time a task finishes, waking AddWait
+	slot chan struct{}
+
+	base    context.Context
+	parent  context.Context
+	timeout time.Duration
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	done    chan error
+
+	// closed when finished, created on demand by Done
+	finished chan struct{}
+
+	// why the group finished, see Reason
+	reason Reason
+
+	// the error returned by Run/Race/Repeat, set once finalized
+	result    error
+	finalized bool
+}
+
+// NewWithContext constructs a Tasks instance bound to the given context.
+// The tasks are cancelled when either this context or the context provided to Run/Race/Repeat is done.
+// Values are only inherited from the context provided to Run/Race/Repeat.
+func NewWithContext(ctx context.Context, tasks ...Task) (ts *Tasks) {
+	ts = New(tasks...)
+	ts.base = ctx
+	return ts
+}
+
+// NewWithTimeout constructs a Tasks instance that is cancelled after the timeout on each Run/Race/Repeat.
+// This is a safety net so a forgotten task can't hang forever; ErrDeadline is returned when it fires.
+// A deadline on the context provided to Run/Race/Repeat still applies if it's earlier, returning its usual error.
+func NewWithTimeout(timeout time.Duration, tasks ...Task) (ts *Tasks) {
+	ts = New(tasks...)
+	ts.timeout = timeout
+	return ts
+}
+
+// job is a task along with the order it was added.
+type job struct {
+	index    int
+	task     Task
+	weight   int64
+	priority int
+}
+
+// result is the error returned by the task at the index.
+type result struct {
+	index int
+	err   error
 }
 
 // New constructs an Tasks instance allowing you to run additional tasks.
 func New(tasks ...Task) (ts *Tasks) {
 	ts = new(Tasks)
-	ts.pending = tasks
+	ts.Add(tasks...)
 	return ts
 }
 
-// Adds tasks to be executed.
+// Adds tasks to be executed, returning a handle that can cancel them without cancelling the group.
 // If Run has already completed, the tasks are executed but immediately cancelled.
-func (ts *Tasks) Add(tasks ...Task) {
+// If the group has been closed, the tasks are dropped.
+// Adding may block or be rejected once the group is full, see WithMaxRunning.
+func (ts *Tasks) Add(tasks ...Task) (h *TaskHandle) {
+	h = new(TaskHandle)
+
+	h.err = ts.lockRoom(context.Background(), len(tasks))
+	if h.err != nil {
+		return h
+	}
+
+	defer ts.mutex.Unlock()
+
+	for _, t := range tasks {
+		ts.add(h.wrap(t), 1)
+	}
+
+	return h
+}
+
+// TryAdd adds tasks to be executed like Add, unless the group has been cancelled, closed, finished, or is full (see WithMaxRunning).
+// Returns false if the tasks were not added, avoiding goroutines that would immediately return.
+func (ts *Tasks) TryAdd(tasks ...Task) (h *TaskHandle, ok bool) {
 	ts.mutex.Lock()
 	defer ts.mutex.Unlock()
 
-	if ts.mode == modeInit {
-		ts.pending = append(ts.pending, tasks...)
-		return
+	if ts.closed || ts.mode == modeDone || (ts.mode != modeInit && ts.ctx.Err() != nil) || ts.full(len(tasks)) {
+		return nil, false
+	}
+
+	h = new(TaskHandle)
+
+	for _, t := range tasks {
+		ts.add(h.wrap(t), 1)
+	}
+
+	return h, true
+}
+
+// AddWithContext adds tasks to be executed with a context derived from the group's context, returning a handle like Add.
+// This allows per-task deadlines or values; the derived context is cancelled when the task returns.
+func (ts *Tasks) AddWithContext(derive func(parent context.Context) (context.Context, context.CancelFunc), tasks ...Task) (h *TaskHandle) {
+	h = new(TaskHandle)
+
+	h.err = ts.lockRoom(context.Background(), len(tasks))
+	if h.err != nil {
+		return h
 	}
 
-	ts.running += len(tasks)
+	defer ts.mutex.Unlock()
 
 	for _, t := range tasks {
-		go ts.run(ts.ctx, t)
+		t := t
+
+		ts.add(h.wrap(func(ctx context.Context) (err error) {
+			ctx, cancel := derive(ctx)
+			defer cancel()
+
+			return t(ctx)
+		}), 1)
 	}
+
+	return h
+}
+
+// AddWeighted adds a task that counts as the given weight towards the limit, returning a handle like Add.
+// Tasks are started in order while the total weight of running tasks is within the limit.
+// A weight larger than the limit is clamped to the limit, so the task will run by itself.
+func (ts *Tasks) AddWeighted(weight int64, t Task) (h *TaskHandle) {
+	h = new(TaskHandle)
+
+	h.err = ts.lockRoom(context.Background(), 1)
+	if h.err != nil {
+		return h
+	}
+
+	defer ts.mutex.Unlock()
+
+	ts.add(h.wrap(t), weight)
+
+	return h
+}
+
+// AddWait adds tasks to be executed, blocking until each can start under the limit, and returns a handle like Add.
+// Queued tasks count towards the limit, including those added before Run.
+// It also blocks until there's room under WithMaxRunning, or returns ErrTooManyTasks if that doesn't block.
+// ErrFinished is returned if Run has already completed, or ErrClosed after Close, and the remaining tasks are not executed.
+// The handle is still returned with an error, covering any tasks that were added.
+func (ts *Tasks) AddWait(ctx context.Context, tasks ...Task) (h *TaskHandle, err error) {
+	h = new(TaskHandle)
+
+	for _, t := range tasks {
+		err = ts.addWait(ctx, h.wrap(t))
+		if err != nil {
+			return h, err
+		}
+	}
+
+	return h, nil
+}
+
+func (ts *Tasks) addWait(ctx context.Context, t Task) (err error) {
+	for {
+		ts.mutex.Lock()
+
+		if ts.closed {
+			ts.mutex.Unlock()
+			return ErrClosed
+		}
+
+		if ts.mode == modeDone {
+			ts.mutex.Unlock()
+			return ErrFinished
+		}
+
+		full := ts.full(1)
+		if full && !ts.maxBlock {
+			ts.mutex.Unlock()
+			return ErrTooManyTasks
+		}
+
+		if !full && (ts.limit == 0 || ts.weight+ts.queued()+1 <= ts.limit) {
+			ts.add(t, 1)
+			ts.mutex.Unlock()
+			return nil
+		}
+
+		if ts.slot == nil {
+			ts.slot = make(chan struct{})
+		}
+
+		slot := ts.slot
+		ts.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-slot:
+		}
+	}
+}
+
+// add queues the task prior to Run, otherwise it starts the task.
+// The mutex must be held.
+func (ts *Tasks) add(t Task, weight int64) {
+	ts.addPriority(t, weight, 0)
+}
+
+// addPriority is the same as add, but queued tasks with a higher priority are started first.
+// The mutex must be held.
+func (ts *Tasks) addPriority(t Task, weight int64, priority int) {
+	if ts.closed {
+		// Dropped, see Close.
+		return
+	}
+
+	j := job{index: ts.next, task: t, weight: weight, priority: priority}
+	ts.next += 1
+
+	switch ts.mode {
+	case modeInit:
+		heap.Push(&ts.pending, j)
+	case modeDone:
+		// Run the task anyway, but it will be immediately cancelled.
+		ts.launch(j)
+	default:
+		ts.start(j)
+	}
+}
+
+// start runs the task in a new goroutine, or queues it if the limit has been reached or paused.
+// The mutex must be held.
+func (ts *Tasks) start(j job) {
+	heap.Push(&ts.pending, j)
+	ts.dequeue()
+}
+
+// wrap applies any options that modify the task.
+func (ts *Tasks) wrap(j job) job {
+	if ts.panicLimit > 0 {
+		j.task = recoverTask(j.task, nil, ts.fullDump)
+	}
+
+	if ts.mode == modeRepeat && (ts.restart != nil || ts.maxIterations > 0) {
+		j.task = ts.restartTask(j.index, j.task)
+	}
+
+	return j
+}
+
+// launch runs the task in a new goroutine.
+// The mutex must be held.
+func (ts *Tasks) launch(j job) {
+	if ts.live == nil {
+		ts.live = make(map[int]bool)
+	}
+
+	j.weight = ts.clamp(j.weight)
+
+	ts.running += 1
+	ts.weight += j.weight
+	ts.live[j.index] = true
+	ts.varsStart()
+
+	go ts.run(ts.ctx, j)
 }
 
 // Run returns the first error result (if any) and cancels any remaining tasks.
+// An ErrPanic is preferred over any other error, even if it was returned later, as it indicates a bug.
 func (ts *Tasks) Run(ctx context.Context) (err error) {
+	_, err = ts.do(ctx, modeRun)
+	return err
+}
+
+// RunResult is the same as Run, but also returns an Outcome describing the execution.
+// The Outcome is nil if the tasks were not executed, ex. ErrRunning or ErrFinished.
+func (ts *Tasks) RunResult(ctx context.Context) (o *Outcome, err error) {
 	return ts.do(ctx, modeRun)
 }
 
+// RunShared is the same as Run, but if the group is already running or finished, it waits for and returns that result instead.
+// This allows multiple callers to share a single execution, each receiving the same error as the caller that ran it.
+// A waiting caller returns early if its own context is done.
+func (ts *Tasks) RunShared(ctx context.Context) (err error) {
+	o, err := ts.do(ctx, modeRun)
+	if o != nil {
+		return err
+	}
+
+	// ErrRunning or ErrFinished, so wait for the final result.
+	select {
+	case <-ts.Done():
+		return ts.Wait()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Race returns the first result and cancels any remaining tasks.
 func (ts *Tasks) Race(ctx context.Context) (err error) {
-	return ts.do(ctx, modeRace)
+	_, err = ts.do(ctx, modeRace)
+	return err
 }
 
-// Repeat returns the first error result and cancels any remaining tasks.
-func (ts *Tasks) Repeat(ctx context.Context) (err error) {
-	return ts.do(ctx, modeRepeat)
+// Any returns nil as soon as one task succeeds and cancels any remaining tasks.
+// If every task fails, the errors are joined in the order they returned, see errors.Join.
+//
+// This differs from Race, which returns the first result whether it's a success or an error.
+// Errors from tasks that lost to a success are available via WithRaceLoser, like Race.
+func (ts *Tasks) Any(ctx context.Context) (err error) {
+	_, err = ts.do(ctx, modeAny)
+	return err
 }
 
-func (ts *Tasks) do(ctx context.Context, m mode) (err error) {
+// RaceSuccess returns nil as soon as one task succeeds, like Any, but doesn't cancel the remaining tasks.
+// If every task fails, the errors are joined in the order they returned, see errors.Join.
+// This is useful for speculative work, ex. warming up several caches where any one is enough to proceed.
+//
+// NOTE: The remaining tasks are detached and keep running until they return or the provided context is done.
+// They will leak if they never return; use Done or Wait to know when they have finished.
+func (ts *Tasks) RaceSuccess(ctx context.Context) (err error) {
 	ts.mutex.Lock()
 
 	switch ts.mode {
@@ -90,24 +472,155 @@ func (ts *Tasks) do(ctx context.Context, m mode) (err error) {
 		return ErrRunning
 	}
 
-	tasks := ts.pending
-	ts.pending = nil
+	if ts.won == nil {
+		ts.won = make(chan struct{})
+	}
+
+	won := ts.won
+	ts.mutex.Unlock()
+
+	errs := make(chan error, 1)
+
+	// Finish in the background so returning early doesn't cancel the remaining tasks.
+	go func() {
+		_, err := ts.do(ctx, modeRaceSuccess)
+		errs <- err
+	}()
+
+	select {
+	case <-won:
+		return nil
+	case err = <-errs:
+		return err
+	}
+}
+
+// RaceVerbose is the same as Race, but also returns any errors from the remaining tasks that were not caused by the cancellation.
+// This is useful to diagnose multiple tasks finishing at the same time.
+func (ts *Tasks) RaceVerbose(ctx context.Context) (extra []error, err error) {
+	_, err = ts.do(ctx, modeRace)
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	return ts.extra, err
+}
+
+// Repeat returns the first error result and cancels any remaining tasks.
+func (ts *Tasks) Repeat(ctx context.Context) (err error) {
+	_, err = ts.do(ctx, modeRepeat)
+	return err
+}
+
+// Done returns a channel that is closed when Run/Race/Repeat has finished.
+func (ts *Tasks) Done() <-chan struct{} {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.finished == nil {
+		ts.finished = make(chan struct{})
+
+		if ts.finalized {
+			close(ts.finished)
+		}
+	}
+
+	return ts.finished
+}
+
+// Running returns true if Run/Race/Repeat has started and not yet finished.
+func (ts *Tasks) Running() bool {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	return ts.mode != modeInit && ts.mode != modeDone
+}
+
+// Err returns the error that will be returned by Run/Race/Repeat, if any has been recorded so far.
+// Once the group has finished, this is the same error that Run/Race/Repeat returned.
+func (ts *Tasks) Err() (err error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.finalized {
+		return ts.result
+	}
+
+	return ts.err
+}
+
+// Wait blocks until Run/Race/Repeat has finished and returns the same error.
+// This is useful for observers that did not call Run themselves.
+func (ts *Tasks) Wait() (err error) {
+	<-ts.Done()
+	return ts.Err()
+}
+
+func (ts *Tasks) do(ctx context.Context, m mode) (o *Outcome, err error) {
+	ts.mutex.Lock()
+
+	switch ts.mode {
+	case modeInit:
+		// expected
+	case modeDone:
+		ts.mutex.Unlock()
+		return nil, ErrFinished
+	default:
+		ts.mutex.Unlock()
+		return nil, ErrRunning
+	}
 
 	// If there are no tasks, advance to done directly.
-	if len(tasks) == 0 && m != modeRepeat {
-		ts.mode = modeDone
+	// Repeat waits for tasks to be added instead, unless AllowEmpty.
+	if len(ts.pending) == 0 && (m != modeRepeat || ts.allowEmpty) {
+		// Any tasks added later are started with a cancelled context.
+		done, cancel := context.WithCancel(ctx)
+		cancel()
+
+		ts.ctx = done
+		ts.setDone(nil)
 		ts.mutex.Unlock()
-		return nil
+
+		// Run any deferred cleanup before Wait returns.
+		ts.cleanup()
+
+		ts.mutex.Lock()
+		ts.finalize(nil)
+		ts.mutex.Unlock()
+
+		return new(Outcome), nil
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	start := time.Now()
+	parent := ctx
+	ts.parent = parent
+
+	if ts.timeout > 0 {
+		// The earlier deadline wins, in which case the cause is not ErrDeadline.
+		var stop context.CancelFunc
+		ctx, stop = context.WithTimeoutCause(ctx, ts.timeout, ErrDeadline)
+		defer stop()
+	}
 
-	ts.mode = m
+	timed := ctx
+
+	// The cause is set to the first error so tasks can see why they were cancelled.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	if ts.base != nil {
+		// Cancel when either the base context or the provided context is done.
+		stop := context.AfterFunc(ts.base, func() {
+			cancel(context.Cause(ts.base))
+		})
+		defer stop()
+	}
+
+	ts.setMode(m)
 	ts.ctx = ctx
 	ts.cancel = cancel
 	ts.first = true
-	ts.running = len(tasks)
+	ts.running = 0
 
 	if m == modeRepeat {
 		// Repeat needs an extra task that always runs to catch context cancel.
@@ -115,63 +628,400 @@ func (ts *Tasks) do(ctx context.Context, m mode) (err error) {
 	}
 
 	ts.done = make(chan error, 1)
-	ts.mutex.Unlock()
 
-	for _, f := range tasks {
-		go ts.run(ctx, f)
-	}
+	// Start the queued tasks in priority order.
+	ts.dequeue()
+
+	// Start any paused tasks on cancel, otherwise we would block until Resume.
+	stop := context.AfterFunc(ctx, ts.unpause)
+	defer stop()
+
+	ts.mutex.Unlock()
 
 	if m == modeRepeat {
 		// We need to run at least one task always to catch context cancel.
-		ts.run(ctx, Wait)
+		ts.wait(ctx)
 	}
 
 	// Wait until all goroutines have exited
-	return <-ts.done
+	err = ts.finish(ctx)
+
+	// Make sure every loser has been reported before returning.
+	ts.notifyLosers()
+
+	duration := time.Since(start)
+
+	// Run any deferred cleanup now that the tasks have returned, before Wait returns.
+	ts.cleanup()
+
+	ts.mutex.Lock()
+
+	if errors.Is(err, context.DeadlineExceeded) && context.Cause(timed) == ErrDeadline {
+		// The default timeout fired, see NewWithTimeout.
+		err = ErrDeadline
+	}
+
+	if ts.rootCause && isCancel(err) {
+		err = ts.externalCause(parent, err)
+	}
+
+	if ts.cancelSuccess && errors.Is(err, context.Canceled) {
+		external := parent.Err() != nil || (ts.base != nil && ts.base.Err() != nil)
+		if !external || ts.cancelExternal {
+			err = nil
+		}
+	}
+
+	ts.finalize(err)
+
+	o = &Outcome{
+		Succeeded: ts.succeeded,
+		Failed:    ts.failed,
+		Duration:  duration,
+		Cause:     ts.cause(ctx),
+	}
+
+	ts.mutex.Unlock()
+
+	return o, err
+}
+
+func (ts *Tasks) run(ctx context.Context, j job) {
+	start := time.Now()
+
+	err := ts.call(ctx, j)
+	if err == errSkipped {
+		ts.skip(j)
+		return
+	}
+
+	if err != nil && ts.taskErrors {
+		err = TaskError{Index: j.index, Err: err}
+	}
+
+	// Hooks are called without the mutex and before the task is reported, so they may call Add.
+	if ts.timing != nil {
+		ts.timing(j.index, time.Since(start), err)
+	}
+
+	if ts.onFinish != nil {
+		ts.onFinish(j.index, err)
+	}
+
+	// Update the counters before grabbing the mutex to avoid contention.
+	ts.varsFinish(err)
+
+	// Called after the mutex is released, so the callback may call Add.
+	defer ts.notifyLosers()
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.check(ts.live[j.index], "task %d reported twice", j.index)
+
+	ts.weight -= j.weight
+	delete(ts.live, j.index)
+
+	if err == nil {
+		ts.succeeded += 1
+	} else {
+		ts.failed += 1
+	}
+
+	if ts.slot != nil {
+		close(ts.slot)
+		ts.slot = nil
+	}
+
+	ts.recordLowest(j.index, err)
+	ts.report(j.index, err)
+}
+
+// skip releases a task that was cancelled by its handle, without reporting a result, see TaskHandle.
+func (ts *Tasks) skip(j job) {
+	ts.varsSkip()
+
+	defer ts.notifyLosers()
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.check(ts.live[j.index], "task %d reported twice", j.index)
+
+	ts.weight -= j.weight
+	delete(ts.live, j.index)
+
+	if ts.slot != nil {
+		close(ts.slot)
+		ts.slot = nil
+	}
+
+	ts.report(j.index, errSkipped)
+}
+
+// lost records the result of a task that didn't win the race.
+// The mutex must be held.
+func (ts *Tasks) lost(index int, err error) {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		// Record any results that were not caused by the cancellation.
+		ts.extra = append(ts.extra, err)
+	}
+
+	ts.loser(index, err)
+}
+
+// loser queues the result for the raceLoser callback, if any.
+// The mutex must be held.
+func (ts *Tasks) loser(index int, err error) {
+	if ts.raceLoser != nil {
+		ts.losers = append(ts.losers, result{index: index, err: err})
+	}
 }
 
-func (ts *Tasks) run(ctx context.Context, t Task) {
-	err := t(ctx)
-	ts.report(err)
+// notifyLosers calls the raceLoser callback for each queued result, in order.
+// The mutex must NOT be held.
+func (ts *Tasks) notifyLosers() {
+	if ts.raceLoser == nil {
+		return
+	}
+
+	ts.loserMutex.Lock()
+	defer ts.loserMutex.Unlock()
+
+	ts.mutex.Lock()
+	losers := ts.losers
+	ts.losers = nil
+	ts.mutex.Unlock()
+
+	for _, r := range losers {
+		ts.raceLoser(r.index, r.err)
+	}
+}
+
+// setDone transitions to modeDone, recording the reason for the error.
+// The mutex must be held.
+func (ts *Tasks) setDone(err error) {
+	ts.reason = ts.reasonOf(err)
+	ts.setMode(modeDone)
+}
+
+// finalize records the error returned by Run/Race/Repeat and closes the Done channel.
+// This is after setDone, once the error has been post-processed, so Wait returns the same error as Run.
+// The mutex must be held.
+func (ts *Tasks) finalize(err error) {
+	ts.result = err
+	ts.finalized = true
+
+	if ts.finished != nil {
+		close(ts.finished)
+	}
 }
 
-func (ts *Tasks) report(err error) {
+// dequeue starts queued tasks while there's capacity and the group is not paused.
+// The mutex must be held.
+func (ts *Tasks) dequeue() {
+	for len(ts.pending) > 0 && ts.fits(ts.pending[0].weight) {
+		// Paused tasks are still started on cancel so the group can finish.
+		if ts.paused && ts.ctx.Err() == nil {
+			return
+		}
+
+		j := heap.Pop(&ts.pending).(job)
+		ts.launch(ts.wrap(j))
+	}
+}
+
+// fits returns true if a task with the given weight can start without exceeding the limit.
+// The mutex must be held.
+func (ts *Tasks) fits(weight int64) bool {
+	return ts.limit == 0 || ts.weight+ts.clamp(weight) <= ts.limit
+}
+
+// clamp reduces the weight to the limit so a heavy task can't block forever.
+func (ts *Tasks) clamp(weight int64) int64 {
+	if ts.limit > 0 && weight > ts.limit {
+		return ts.limit
+	}
+
+	return weight
+}
+
+// queued returns the total weight of the queued tasks.
+// The mutex must be held.
+func (ts *Tasks) queued() (weight int64) {
+	for _, j := range ts.pending {
+		weight += j.weight
+	}
+
+	return weight
+}
+
+// wait blocks until the context is done, preventing Repeat from finishing without an error.
+func (ts *Tasks) wait(ctx context.Context) {
+	err := Wait(ctx)
+	if context.Cause(ctx) == errIterated {
+		// Every task finished cleanly, see WithMaxIterations.
+		err = nil
+	}
+
 	ts.mutex.Lock()
 	defer ts.mutex.Unlock()
 
+	// Not a real task, so there's no index.
+	ts.report(-1, err)
+}
+
+// report records the result of the task at the index and starts any queued tasks.
+// The mutex must be held.
+func (ts *Tasks) report(index int, err error) {
 	ts.running -= 1
+	ts.check(ts.running >= 0, "task %d reported with nothing running", index)
 
+	if ts.mode == modeDone {
+		// already done
+		return
+	}
+
+	// A task cancelled by its handle has no result, see TaskHandle.
+	if err != errSkipped {
+		ts.record(index, err)
+	}
+
+	// Start any queued tasks now that there's capacity.
+	ts.dequeue()
+
+	if ts.mode == modeRepeat && ts.maxIterations > 0 && ts.running == 1 && len(ts.pending) == 0 && ts.err == nil && !ts.iterated {
+		// Only the wait task remains, so stop it.
+		ts.iterated = true
+		ts.cancel(errIterated)
+	}
+
+	if ts.running > 0 || len(ts.pending) > 0 {
+		return
+	}
+
+	ts.complete()
+}
+
+// record handles the result of the task at the index, depending on the mode.
+// The mutex must be held.
+func (ts *Tasks) record(index int, err error) {
 	switch ts.mode {
 	case modeRun, modeRepeat:
 		if ts.err == nil {
 			ts.err = err
+		} else if isPanic(err) && !isPanic(ts.err) {
+			// A panic indicates a bug, so it's more important than any other error.
+			ts.err = err
+		} else if isCancel(ts.err) && isSignal(err) {
+			// The signal is what the operator expects to see, even if it raced with the cancellation it caused.
+			ts.err = err
+		} else if ts.rootCause && err != nil && isCancel(ts.err) && !isCancel(err) {
+			// A task error is more useful than the cancellation, see WithRootCause.
+			ts.err = err
 		}
 
 		if err != nil {
-			ts.cancel()
+			ts.cancel(err)
 		}
 	case modeRace:
+		if ts.first && err != nil && ts.raceIgnore != nil && ts.raceIgnore(err) {
+			// Keep waiting for a better result.
+			ts.ignored = append(ts.ignored, result{index: index, err: err})
+			break
+		}
+
 		if ts.first {
 			ts.err = err
 			ts.first = false
+		} else {
+			ts.lost(index, err)
 		}
 
-		ts.cancel()
-	case modeDone:
-		// already done
-		return
+		// Any ignored results lost the race.
+		for _, r := range ts.ignored {
+			ts.lost(r.index, r.err)
+		}
+
+		ts.ignored = nil
+
+		ts.cancel(err)
+	case modeAny:
+		if !ts.first {
+			ts.lost(index, err)
+			break
+		}
+
+		if err != nil {
+			// Keep waiting for a success.
+			ts.failures = append(ts.failures, result{index: index, err: err})
+			break
+		}
+
+		// The earlier failures lost too.
+		for _, r := range ts.failures {
+			ts.loser(r.index, r.err)
+		}
+
+		ts.failures = nil
+
+		ts.err = nil
+		ts.first = false
+		ts.cancel(nil)
+	case modeRaceSuccess:
+		if err != nil {
+			ts.failures = append(ts.failures, result{index: index, err: err})
+			break
+		}
+
+		if ts.first {
+			// Unblock RaceSuccess, but leave the remaining tasks running.
+			ts.first = false
+			close(ts.won)
+		}
 	}
+}
 
-	if ts.running > 0 {
-		return
+// complete is called once the last task has returned, finishing the group unless it's a Repeat.
+// The mutex must be held.
+func (ts *Tasks) complete() {
+	ts.check(ts.mode != modeDone, "completed after done")
+
+	// Every result was ignored, so the first one wins after all.
+	if ts.mode == modeRace && ts.first && len(ts.ignored) > 0 {
+		ts.err = ts.ignored[0].err
+		ts.first = false
+
+		for _, r := range ts.ignored[1:] {
+			ts.lost(r.index, r.err)
+		}
+
+		ts.ignored = nil
+	}
+
+	// Every task failed, so return all of the errors.
+	if (ts.mode == modeAny || ts.mode == modeRaceSuccess) && ts.first && len(ts.failures) > 0 {
+		errs := make([]error, 0, len(ts.failures))
+		for _, r := range ts.failures {
+			errs = append(errs, r.err)
+		}
+
+		ts.err = errors.Join(errs...)
+	}
+
+	ts.failures = nil
+
+	if ts.lowest != nil && ts.mode != modeRace && ts.mode != modeAny && ts.mode != modeRaceSuccess {
+		ts.err = ts.lowest
 	}
 
-	// We're the last task, so send it to unblock the `do` goroutine.
+	// The last task has returned, so send it to unblock the `do` goroutine.
 	// Unless we called Repeat because that will continue until an error.
 
-	if ts.mode != modeRepeat || ts.err != nil {
+	if ts.mode != modeRepeat || ts.err != nil || ts.iterated {
 		// NOTE: This will be written to exactly once.
+		ts.check(len(ts.done) == 0, "result written twice")
 		ts.done <- ts.err
-		ts.mode = modeDone
+		ts.setDone(ts.err)
 	}
 }
@@ -0,0 +1,23 @@
+package invoker
+
+import (
+	"context"
+	"runtime"
+)
+
+// LockOSThread returns a Task that runs the task while locked to the current OS thread.
+// This is needed for some C libraries that require callbacks on the same thread.
+// NOTE: The thread is intentionally left locked if the task panics, even if recovered by Recover or WithPanicLimit.
+// The goroutine exits afterwards, so the thread is discarded rather than reused in an unknown state.
+func LockOSThread(t Task) Task {
+	return func(ctx context.Context) (err error) {
+		runtime.LockOSThread()
+
+		// Not deferred on purpose, see above.
+		err = t(ctx)
+
+		runtime.UnlockOSThread()
+
+		return err
+	}
+}
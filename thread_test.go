@@ -0,0 +1,56 @@
+//go:build linux
+
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the task observes the same thread throughout.
+func TestLockOSThread(t *testing.T) {
+	require := require.New(t)
+
+	f := func(ctx context.Context) (err error) {
+		tid := syscall.Gettid()
+
+		for i := 0; i < 100; i += 1 {
+			// Give the scheduler a chance to move us.
+			runtime.Gosched()
+
+			if syscall.Gettid() != tid {
+				return fmt.Errorf("thread changed")
+			}
+		}
+
+		return nil
+	}
+
+	tasks := invoker.New()
+	for i := 0; i < 8; i += 1 {
+		tasks.Add(invoker.LockOSThread(f))
+	}
+
+	err := tasks.Run(context.Background())
+	require.NoError(err)
+}
+
+// Test that a recovered panic is still returned as an error.
+func TestLockOSThreadPanic(t *testing.T) {
+	require := require.New(t)
+
+	f := func(ctx context.Context) (err error) {
+		panic("hello")
+	}
+
+	err := invoker.Run(context.Background(), invoker.Recover(invoker.LockOSThread(f)))
+
+	require.IsType(invoker.ErrPanic{}, err)
+	require.Equal("hello", err.(invoker.ErrPanic).Value())
+}
@@ -2,9 +2,25 @@ package invoker
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 )
 
+// ErrIdle is returned by IdleTimeout when there was no activity for the duration.
+var ErrIdle = fmt.Errorf("idle timeout")
+
+// ErrDeadline is returned by Deadlines when the hard deadline fired, or when the default timeout of NewWithTimeout fired.
+// It wraps context.DeadlineExceeded, but can be told apart from a deadline set by the caller.
+var ErrDeadline = fmt.Errorf("hard deadline: %w", context.DeadlineExceeded)
+
+// ErrInterrupted is returned by Delay when the context is done before the duration has elapsed.
+var ErrInterrupted = fmt.Errorf("delay interrupted")
+
+// ErrTimeout is returned when a helper's own timeout fired, as opposed to the parent's deadline.
+// It wraps context.DeadlineExceeded.
+var ErrTimeout = fmt.Errorf("invoker timeout: %w", context.DeadlineExceeded)
+
 // Return a Task that runs for the given amount of time before erroring.
 func Timeout(duration time.Duration) Task {
 	return func(ctx context.Context) (err error) {
@@ -30,3 +46,148 @@ func Timer(duration time.Duration) Task {
 		}
 	}
 }
+
+// Return a Task that runs for the given amount of time before returning nil, like Timer.
+// If the context is done first, ErrInterrupted is returned instead so a completed wait can be told apart from an aborted one.
+func Delay(duration time.Duration) Task {
+	return func(ctx context.Context) (err error) {
+		err = Timer(duration)(ctx)
+		if err != nil {
+			return ErrInterrupted
+		}
+
+		return nil
+	}
+}
+
+// Return a Task that waits until the given time before running the task.
+// The task is run immediately if the time is in the past.
+func At(t time.Time, fn Task) Task {
+	return func(ctx context.Context) (err error) {
+		err = Timer(time.Until(t))(ctx)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx)
+	}
+}
+
+// Return a Task that errors with ErrIdle unless the touch function is called within the duration.
+// Each call to touch resets the timer, so the Task runs indefinitely while there is activity.
+func IdleTimeout(duration time.Duration) (t Task, touch func()) {
+	touched := make(chan struct{}, 1)
+
+	touch = func() {
+		select {
+		case touched <- struct{}{}:
+		default:
+			// already touched
+		}
+	}
+
+	t = func(ctx context.Context) (err error) {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+				return ErrIdle
+			case <-touched:
+				if !timer.Stop() {
+					<-timer.C
+				}
+
+				timer.Reset(duration)
+			}
+		}
+	}
+
+	return t, touch
+}
+
+// Return a Task that runs the task every day at the given local time, until it errors or the context is done.
+// If the time has already passed today, the first run is tomorrow.
+func Daily(hour int, minute int, loc *time.Location, fn Task) Task {
+	return func(ctx context.Context) (err error) {
+		for {
+			next := NextDaily(time.Now(), hour, minute, loc)
+
+			err = Sleep(time.Until(next))(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = fn(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// NextDaily returns the next occurrence of the local time strictly after now, as used by Daily.
+// On DST transitions, a time that doesn't exist is moved forward, ex. 2:30 becomes 3:30.
+func NextDaily(now time.Time, hour int, minute int, loc *time.Location) time.Time {
+	now = now.In(loc)
+
+	next := localTime(now.Year(), now.Month(), now.Day(), hour, minute, loc)
+	if next.After(now) {
+		return next
+	}
+
+	// Use the date rather than adding 24 hours, which would be wrong across DST.
+	return localTime(now.Year(), now.Month(), now.Day()+1, hour, minute, loc)
+}
+
+// localTime returns the given local time, moving it forward if it was skipped by a DST transition.
+func localTime(year int, month time.Month, day int, hour int, minute int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, minute, 0, 0, loc)
+	if t.Hour() == hour && t.Minute() == minute {
+		return t
+	}
+
+	// The time doesn't exist, so use the offset from before the transition.
+	_, offset := time.Date(year, month, day, 0, 0, 0, 0, loc).Zone()
+
+	return time.Date(year, month, day, hour, minute, 0, 0, time.FixedZone("", offset)).In(loc)
+}
+
+// Return a Task that runs the task with a soft and a hard deadline.
+// The onSoft function is called once the soft deadline passes, ex. to start winding down, without cancelling the task.
+// The task is cancelled at the hard deadline, returning ErrDeadline if it then errored.
+// The onSoft function is not called if the task has already returned, and the Task doesn't return while it's running.
+func Deadlines(soft time.Duration, hard time.Duration, onSoft func(ctx context.Context), fn Task) Task {
+	return func(ctx context.Context) (err error) {
+		ctx, cancel := context.WithTimeoutCause(ctx, hard, ErrDeadline)
+		defer cancel()
+
+		var mutex sync.Mutex
+		finished := false
+
+		timer := time.AfterFunc(soft, func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if !finished {
+				onSoft(ctx)
+			}
+		})
+		defer timer.Stop()
+
+		err = fn(ctx)
+
+		mutex.Lock()
+		finished = true
+		mutex.Unlock()
+
+		if err != nil && context.Cause(ctx) == ErrDeadline {
+			return ErrDeadline
+		}
+
+		return err
+	}
+}
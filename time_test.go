@@ -0,0 +1,217 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the task runs once the time arrives.
+func TestAt(t *testing.T) {
+	require := require.New(t)
+
+	when := time.Now().Add(10 * time.Millisecond)
+
+	var ran time.Time
+	f := func(ctx context.Context) (err error) {
+		ran = time.Now()
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.At(when, f))
+	require.NoError(err)
+	require.False(ran.Before(when))
+}
+
+// Test that a time in the past runs immediately.
+func TestAtPast(t *testing.T) {
+	require := require.New(t)
+
+	ran := false
+	f := func(ctx context.Context) (err error) {
+		ran = true
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.At(time.Now().Add(-time.Hour), f))
+	require.NoError(err)
+	require.True(ran)
+}
+
+// Test that cancelling before the time means the task never runs.
+func TestAtCancel(t *testing.T) {
+	require := require.New(t)
+
+	ran := false
+	f := func(ctx context.Context) (err error) {
+		ran = true
+		return nil
+	}
+
+	err := invoker.Run(context.Background(), invoker.At(time.Now().Add(time.Hour), f), invoker.Timeout(time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.False(ran)
+}
+
+// Test that activity prevents the idle timeout.
+func TestIdleTimeoutActive(t *testing.T) {
+	require := require.New(t)
+
+	idle, touch := invoker.IdleTimeout(20 * time.Millisecond)
+
+	active := func(ctx context.Context) (err error) {
+		for i := 0; i < 10; i += 1 {
+			touch()
+
+			err = invoker.Sleep(5 * time.Millisecond)(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err := invoker.Race(context.Background(), idle, active)
+	require.NoError(err)
+}
+
+// Test that no activity returns ErrIdle.
+func TestIdleTimeoutIdle(t *testing.T) {
+	require := require.New(t)
+
+	idle, touch := invoker.IdleTimeout(5 * time.Millisecond)
+	touch()
+
+	err := invoker.Run(context.Background(), idle, invoker.Wait)
+	require.Equal(invoker.ErrIdle, err)
+}
+
+// Test that Delay returns nil once the full duration has elapsed.
+func TestDelay(t *testing.T) {
+	require := require.New(t)
+
+	start := time.Now()
+
+	err := invoker.Run(context.Background(), invoker.Delay(10*time.Millisecond))
+	require.NoError(err)
+	require.True(time.Since(start) >= 10*time.Millisecond)
+}
+
+// Test that Delay returns ErrInterrupted when cancelled early.
+func TestDelayCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := invoker.Run(ctx, invoker.Delay(time.Hour))
+	require.Equal(invoker.ErrInterrupted, err)
+}
+
+// Test the next occurrence of a daily time, including across DST.
+func TestNextDaily(t *testing.T) {
+	require := require.New(t)
+
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(err)
+
+	// Later today.
+	now := time.Date(2024, 3, 8, 6, 0, 0, 0, loc)
+	require.Equal(time.Date(2024, 3, 8, 7, 0, 0, 0, loc), invoker.NextDaily(now, 7, 0, loc))
+
+	// Already passed, so tomorrow.
+	now = time.Date(2024, 3, 8, 7, 0, 0, 0, loc)
+	require.Equal(time.Date(2024, 3, 9, 7, 0, 0, 0, loc), invoker.NextDaily(now, 7, 0, loc))
+
+	// Across the spring forward, only 23 hours later but still 7:00 local.
+	now = time.Date(2024, 3, 9, 8, 0, 0, 0, loc)
+	next := invoker.NextDaily(now, 7, 0, loc)
+	require.Equal(7, next.Hour())
+	require.Equal(10, next.Day())
+	require.Equal(23*time.Hour, next.Sub(time.Date(2024, 3, 9, 7, 0, 0, 0, loc)))
+
+	// 2:30 doesn't exist on the day of the spring forward, so it's moved forward.
+	next = invoker.NextDaily(now, 2, 30, loc)
+	require.Equal(10, next.Day())
+	require.Equal(3, next.Hour())
+	require.Equal(30, next.Minute())
+}
+
+// Test that Daily waits for the next occurrence and returns on cancel.
+func TestDailyCancel(t *testing.T) {
+	require := require.New(t)
+
+	called := false
+	f := func(ctx context.Context) (err error) {
+		called = true
+		return nil
+	}
+
+	// Pick a time that's never within the next few milliseconds.
+	now := time.Now()
+	hour := (now.Hour() + 12) % 24
+
+	err := invoker.Run(context.Background(), invoker.Daily(hour, 0, time.Local, f), invoker.Timeout(10*time.Millisecond))
+	require.Equal(context.DeadlineExceeded, err)
+	require.False(called)
+}
+
+// Test that onSoft isn't called if the task finishes early.
+func TestDeadlinesEarly(t *testing.T) {
+	require := require.New(t)
+
+	called := false
+	onSoft := func(ctx context.Context) {
+		called = true
+	}
+
+	err := invoker.Run(context.Background(), invoker.Deadlines(10*time.Millisecond, time.Hour, onSoft, invoker.Noop))
+	require.NoError(err)
+
+	time.Sleep(20 * time.Millisecond)
+	require.False(called)
+}
+
+// Test that onSoft is called once, and the task can then finish before the hard deadline.
+func TestDeadlinesSoft(t *testing.T) {
+	require := require.New(t)
+
+	soft := make(chan struct{})
+	onSoft := func(ctx context.Context) {
+		close(soft)
+	}
+
+	f := func(ctx context.Context) (err error) {
+		select {
+		case <-soft:
+			// Wind down.
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	err := invoker.Run(context.Background(), invoker.Deadlines(time.Millisecond, time.Hour, onSoft, f))
+	require.NoError(err)
+}
+
+// Test that the task is cancelled at the hard deadline.
+func TestDeadlinesHard(t *testing.T) {
+	require := require.New(t)
+
+	count := 0
+	onSoft := func(ctx context.Context) {
+		count += 1
+	}
+
+	err := invoker.Run(context.Background(), invoker.Deadlines(time.Millisecond, 10*time.Millisecond, onSoft, invoker.Wait))
+	require.Equal(invoker.ErrDeadline, err)
+	require.True(errors.Is(err, context.DeadlineExceeded))
+	require.Equal(1, count)
+}
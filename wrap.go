@@ -0,0 +1,32 @@
+package invoker
+
+import "context"
+
+// Wrap returns a Task that runs a function that doesn't take a context.
+// The function is run in a goroutine so the Task can return early when the context is done.
+// NOTE: The function will continue running in the background until it returns.
+func Wrap(fn func() error) (t Task) {
+	return func(ctx context.Context) (err error) {
+		done := make(chan error, 1)
+
+		go func() {
+			done <- fn()
+		}()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err = <-done:
+			return err
+		}
+	}
+}
+
+// WrapNoErr returns a Task that runs a function that doesn't take a context or return an error.
+// See Wrap for more details.
+func WrapNoErr(fn func()) (t Task) {
+	return Wrap(func() error {
+		fn()
+		return nil
+	})
+}
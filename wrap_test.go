@@ -0,0 +1,54 @@
+package invoker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kixelated/invoker"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the wrapped error is returned.
+func TestWrap(t *testing.T) {
+	require := require.New(t)
+
+	errSample := fmt.Errorf("hello")
+
+	err := invoker.Run(context.Background(), invoker.Wrap(func() error {
+		return errSample
+	}))
+	require.Equal(errSample, err)
+}
+
+// Test that the wrapped function is run.
+func TestWrapNoErr(t *testing.T) {
+	require := require.New(t)
+
+	called := false
+
+	err := invoker.Run(context.Background(), invoker.WrapNoErr(func() {
+		called = true
+	}))
+	require.NoError(err)
+	require.True(called)
+}
+
+// Test that the task returns on cancel while the function is still running.
+func TestWrapCancel(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	f := invoker.WrapNoErr(func() {
+		cancel()
+		<-release
+	})
+
+	err := invoker.Run(ctx, f)
+	require.Equal(context.Canceled, err)
+}